@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 
@@ -9,6 +11,12 @@ import (
 	"tgbot/internal/handler"
 	"tgbot/internal/redis"
 	"tgbot/internal/repository"
+	"tgbot/internal/scheduler"
+	"tgbot/internal/server"
+	"tgbot/internal/service/digest"
+	"tgbot/internal/service/purge"
+	"tgbot/internal/service/recurring"
+	"tgbot/internal/service/report"
 )
 
 func main() {
@@ -32,13 +40,37 @@ func main() {
 		logger.Panic("failed to load texts", zap.Error(err))
 	}
 
+	langs := make(map[string]map[string]string, len(cfg.Languages))
+	for lang, path := range cfg.Languages {
+		langs[lang], err = assets.LoadJSON(path)
+		if err != nil {
+			logger.Panic("failed to load language texts", zap.String("lang", lang), zap.Error(err))
+		}
+	}
+
 	logger.Info("All Databases connected successful!")
 	logger.Info("Authorized on account", zap.String("account", bot.Self.UserName))
 
 	u := tgbotapi.NewUpdate(0)
 
 	updates := bot.GetUpdatesChan(u)
-	r := handler.NewReader(logger, rdbClient, repo, bot, texts)
+	r := handler.NewReader(logger, rdbClient, repo, bot, texts, langs)
+
+	err = r.PublishMenu()
+	if err != nil {
+		logger.Error("failed to publish bot menu", zap.Error(err))
+	}
+
+	sched := scheduler.NewScheduler(logger)
+	sched.Every("recurring tasks", time.Minute, recurring.NewRecurringService(logger, repo, bot, texts).SpawnNext)
+	sched.Every("weekly reports", time.Minute, report.NewReportService(logger, repo, bot, texts).SendDue)
+	sched.Every("morning digests", time.Minute, digest.NewDigestService(logger, repo, bot, texts).SendDue)
+	sched.Every("purge deleted tasks", time.Hour, purge.NewPurgeService(logger, repo, cfg.DeletedRetention).PurgeDeleted)
+	sched.Start()
+
+	if cfg.HTTP != nil && cfg.HTTP.Addr != "" {
+		server.NewServer(logger, repo, cfg.HTTP.Addr, cfg.HTTP.AdminToken).Start()
+	}
 
 	logger.Info("All services are running!")
 	r.ReadUpdates(updates)
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +14,22 @@ type Config struct {
 	DB        *DB
 	RedisDB   *RedisDB
 	TextsPath string
+	Languages map[string]string
+	PageSize  int
+	HTTP      *HTTP
+	// UndoWindow is how long a deleted task can be restored with its Undo button.
+	UndoWindow time.Duration
+	// DeletedRetention is how long deleted tasks are kept before they are purged.
+	DeletedRetention time.Duration
+}
+
+// HTTP configures the HTTP server of the bot. It is not started without Addr.
+type HTTP struct {
+	Addr string
+	// PublicURL is the address users reach the server at, e.g. "https://bot.example.com".
+	PublicURL string
+	// AdminToken is the bearer token of the admin API. The API is off without it.
+	AdminToken string
 }
 
 type RedisDB struct {
@@ -29,6 +46,12 @@ type DB struct {
 	SSLMode  string
 }
 
+const (
+	defaultPageSize         = 5
+	defaultUndoWindow       = 10 * time.Minute
+	defaultDeletedRetention = 30 * 24 * time.Hour
+)
+
 var C *Config
 
 func LoadConfig() *Config {
@@ -51,6 +74,16 @@ func LoadConfig() *Config {
 		log.Fatalf("failed marshal config: %v", err)
 	}
 
+	if c.PageSize <= 0 {
+		c.PageSize = defaultPageSize
+	}
+	if c.UndoWindow <= 0 {
+		c.UndoWindow = defaultUndoWindow
+	}
+	if c.DeletedRetention < c.UndoWindow {
+		c.DeletedRetention = max(defaultDeletedRetention, c.UndoWindow)
+	}
+
 	C = &c
 
 	return &c
@@ -3,10 +3,12 @@ package handler
 import (
 	"tgbot/internal/model"
 	"tgbot/internal/service/callback"
+	"tgbot/internal/service/policy"
 )
 
 type CallBackHandlers struct {
 	Handlers map[string]model.Handler
+	guard    *Guard
 }
 
 func (h *CallBackHandlers) GetHandler(command string) model.Handler {
@@ -16,9 +18,51 @@ func (h *CallBackHandlers) GetHandler(command string) model.Handler {
 func (h *CallBackHandlers) Init(cs *callback.Service) {
 	h.OnCommand("/yes", cs.Yes)
 	h.OnCommand("/no", cs.No)
+	h.OnCommand("/page", cs.Page)
+	h.OnCommand("/filter", cs.Filter)
+	h.OnCommand("/export", cs.Export)
+	h.OnCommand("/import_confirm", cs.ImportConfirm)
+	h.OnCommand("/import_cancel", cs.ImportCancel)
+	h.OnCommand("/history", cs.History)
+	h.OnCommand("/card", cs.Card)
+	h.OnCommand("/card_open", cs.CardOpen)
+	h.OnCommand("/card_done", cs.CardDone)
+	h.OnCommand("/card_edit", cs.CardEdit)
+	h.OnCommand("/card_edit_field", cs.CardEditField)
+	h.OnCommand("/card_deadline_ok", cs.CardDeadlineOK)
+	h.OnCommand("/card_priority", cs.CardPriority)
+	h.OnCommand("/priority", cs.Priority)
+	h.OnCommand("/deadline_ok", cs.DeadlineOK)
+	h.OnCommand("/deadline_retry", cs.DeadlineRetry)
+	h.OnCommand("/card_delete", cs.CardDelete)
+	h.OnCommand("/card_delete_yes", cs.CardDeleteYes)
+	h.OnCommand("/task_undo", cs.TaskUndo)
+	h.OnCommand("/card_reassign", cs.CardReassign)
+	h.OnCommand("/card_reassign_to", cs.CardReassignTo)
+	h.OnCommand("/card_postpone", cs.CardPostpone)
+	h.OnCommand("/card_postpone_by", cs.CardPostponeBy)
+	h.OnCommand("/create_backlog", cs.CreateBacklog)
+	h.OnCommand("/assign_accept", cs.AssignAccept)
+	h.OnCommand("/assign_policy", cs.AssignPolicy)
+	h.OnCommand("/card_claim", cs.CardClaim)
+	h.OnCommand("/card_labels", cs.CardLabels)
+	h.OnCommand("/card_label", cs.CardLabel)
+	h.OnCommand("/card_checklist", cs.CardChecklist)
+	h.OnCommand("/card_checklist_add", cs.CardChecklistAdd)
+	h.OnCommand("/card_item", cs.CardItem)
+	h.OnCommand("/card_comments", cs.CardComments)
+	h.OnCommand("/card_comment", cs.CardComment)
+	h.OnCommand("/card_watch", cs.CardWatch)
+	h.OnCommand("/card_attachments", cs.CardAttachments)
+	h.OnCommand("/card_attach", cs.CardAttach)
+	h.OnCommand("/attachment_send", cs.AttachmentSend)
+	h.OnCommand("/card_series", cs.CardSeries)
+	h.OnCommand("/series_pause", cs.SeriesPause)
+	h.OnCommand("/series_resume", cs.SeriesResume)
+	h.OnCommand("/series_end", cs.SeriesEnd)
 	// Start commands
 }
 
 func (h *CallBackHandlers) OnCommand(command string, handler model.Handler) {
-	h.Handlers[command] = handler
+	h.Handlers[command] = h.guard.Wrap(policy.Callbacks, command, handler)
 }
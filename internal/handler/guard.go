@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+	"tgbot/internal/pkg/utils"
+	"tgbot/internal/service/policy"
+)
+
+// Guard runs command handlers only when the policy allows their action.
+type Guard struct {
+	policy *policy.Service
+	bot    *tgbotapi.BotAPI
+	texts  map[string]string
+}
+
+// Wrap checks the actor part of the action of the command in actions before
+// the handler, which checks the resource, and tells the user why it is
+// denied. Commands without an action are denied.
+func (g *Guard) Wrap(actions map[string]policy.Action, command string, handler model.Handler) model.Handler {
+	action := actions[command]
+	return func(s *model.Situation) error {
+		err := g.policy.Gate(s.User.ID, action)
+		var denied *policy.Denied
+		if errors.As(err, &denied) {
+			_, err = g.bot.Send(tgbotapi.NewMessage(s.User.ID, utils.GetFormatText(g.texts, denied.Key)))
+			if err != nil {
+				return fmt.Errorf("send denial: %w", err)
+			}
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return handler(s)
+	}
+}
@@ -2,11 +2,15 @@ package handler
 
 import (
 	"tgbot/internal/model"
+	"tgbot/internal/service/menu"
 	"tgbot/internal/service/message"
+	"tgbot/internal/service/policy"
 )
 
 type MessageHandlers struct {
 	Handlers map[string]model.Handler
+	menu     *menu.Service
+	guard    *Guard
 }
 
 func (h *MessageHandlers) GetHandler(command string) model.Handler {
@@ -14,30 +18,62 @@ func (h *MessageHandlers) GetHandler(command string) model.Handler {
 }
 
 func (h *MessageHandlers) Init(ms *message.Service) {
-	h.OnCommand("/start", ms.Start)
-	h.OnCommand("/sign_up", ms.SignUp)
+	h.OnMenuCommand("/start", model.MenuPrivate|model.MenuGroup, ms.Start)
+	h.OnMenuCommand("/sign_up", model.MenuPrivate, ms.SignUp)
 	h.OnCommand("/login", ms.Login)
 	h.OnCommand("/password", ms.Password)
 	h.OnCommand("/unrecognized", ms.Password)
-	h.OnCommand("/team", ms.Team)
-	h.OnCommand("/create_team", ms.CreateTeam)
+	h.OnMenuCommand("/team", model.MenuPrivate, ms.Team)
+	h.OnMenuCommand("/create_team", model.MenuPrivate, ms.CreateTeam)
 	h.OnCommand("/team_created", ms.TeamCreated)
-	h.OnCommand("/your_team", ms.YourTeam)
-	h.OnCommand("/add_user", ms.AddUser)
+	h.OnMenuCommand("/your_team", model.MenuPrivate, ms.YourTeam)
+	h.OnMenuCommand("/add_user", model.MenuTeamAdmin, ms.AddUser)
 	h.OnCommand("/add_user_team", ms.AddUserTeam)
-	h.OnCommand("/delete_user", ms.DeleteUser)
+	h.OnMenuCommand("/delete_user", model.MenuTeamAdmin, ms.DeleteUser)
+	h.OnMenuCommand("/assign_policy", model.MenuTeamAdmin, ms.AssignPolicy)
+	h.OnMenuCommand("/report", model.MenuTeamAdmin, ms.Report)
+	h.OnMenuCommand("/import", model.MenuTeamAdmin, ms.Import)
+	h.OnMenuCommand("/history", model.MenuTeamAdmin, ms.History)
+	h.OnCommand("/import_file", ms.ImportFile)
 	h.OnCommand("/user_deleted", ms.DeletedUser)
-	h.OnCommand("/exit_team", ms.ExitTeam)
-	h.OnCommand("/create_task", ms.CreateTask)
+	h.OnMenuCommand("/exit_team", model.MenuPrivate, ms.ExitTeam)
+	h.OnMenuCommand("/create_task", model.MenuPrivate, ms.CreateTask)
 	h.OnCommand("/complexity", ms.Complexity)
 	h.OnCommand("/deadline", ms.DeadLine)
 	h.OnCommand("/description", ms.Description)
 	h.OnCommand("/task_created", ms.TaskCreated)
-	h.OnCommand("/check_tasks", ms.CheckTasks)
+	h.OnMenuCommand("/check_tasks", model.MenuPrivate, ms.CheckTasks)
+	h.OnMenuCommand("/next", model.MenuPrivate, ms.Next)
+	h.OnMenuCommand("/tasks", model.MenuPrivate, ms.Tasks)
+	h.OnMenuCommand("/export", model.MenuPrivate, ms.Export)
+	h.OnMenuCommand("/calendar", model.MenuPrivate, ms.Calendar)
+	h.OnMenuCommand("/backlog", model.MenuPrivate, ms.Backlog)
+	h.OnMenuCommand("/labels", model.MenuPrivate, ms.Labels)
+	h.OnMenuCommand("/available", model.MenuPrivate, ms.Available)
+	h.OnMenuCommand("/digest", model.MenuPrivate, ms.Digest)
+	h.OnMenuCommand("/search", model.MenuPrivate, ms.Search)
+	h.OnMenuCommand("/timezone", model.MenuPrivate, ms.TimeZone)
+	h.OnMenuCommand("/repeat", model.MenuPrivate, ms.Repeat)
+	h.OnMenuCommand("/block", model.MenuPrivate, ms.Block)
+	h.OnMenuCommand("/unblock", model.MenuPrivate, ms.Unblock)
 	h.OnCommand("/task_delete", ms.DeleteTask)
 	h.OnCommand("/task_deleted", ms.TaskDeleted)
+	h.OnMenuCommand("/edit_task", model.MenuPrivate, ms.EditTask)
+	h.OnCommand("/card_edited", ms.CardEdited)
+	h.OnCommand("/checklist_added", ms.ChecklistAdded)
+	h.OnCommand("/comment_added", ms.CommentAdded)
+	h.OnCommand("/comment_reply", ms.CommentReply)
+	h.OnCommand("/attachment_added", ms.AttachmentAdded)
+	h.OnCommand("/attachment_reply", ms.AttachmentReply)
 }
 
 func (h *MessageHandlers) OnCommand(command string, handler model.Handler) {
-	h.Handlers[command] = handler
+	h.Handlers[command] = h.guard.Wrap(policy.Commands, command, handler)
+}
+
+// OnMenuCommand registers a handler and publishes its command in the bot menu
+// of the given scopes.
+func (h *MessageHandlers) OnMenuCommand(command string, scopes model.MenuScope, handler model.Handler) {
+	h.OnCommand(command, handler)
+	h.menu.Register(command, scopes)
 }
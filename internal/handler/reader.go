@@ -10,10 +10,13 @@ import (
 
 	"tgbot/internal/assets"
 	"tgbot/internal/model"
+	"tgbot/internal/pkg/utils"
 	rdb "tgbot/internal/redis"
 	"tgbot/internal/repository"
 	"tgbot/internal/service/callback"
+	"tgbot/internal/service/menu"
 	"tgbot/internal/service/message"
+	"tgbot/internal/service/policy"
 )
 
 const (
@@ -26,21 +29,52 @@ type Reader struct {
 	bot      *tgbotapi.BotAPI
 	logger   *zap.Logger
 	rdb      *redis.Client
+	repo     *repository.PGRepository
 	msg      *MessageHandlers
 	callback *CallBackHandlers
+	menu     *menu.Service
 }
 
-func NewReader(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Reader {
+func NewReader(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string, langs map[string]map[string]string) *Reader {
+	ms := menu.NewMenuService(log, repo, bot, texts, langs)
+	ps := policy.NewPolicyService(repo)
+	guard := &Guard{policy: ps, bot: bot, texts: texts}
 	return &Reader{
 		logger:   log,
 		rdb:      rdb,
+		repo:     repo,
 		bot:      bot,
-		msg:      newMessagesHandler(message.NewMessageService(log, rdb, repo, bot, texts)),
-		callback: newCallbackHandler(callback.NewCallbackService(log, rdb, repo, bot, texts)),
+		msg:      newMessagesHandler(message.NewMessageService(log, rdb, repo, bot, texts, ms, ps), ms, guard),
+		callback: newCallbackHandler(callback.NewCallbackService(log, rdb, repo, bot, texts, ps), guard),
+		menu:     ms,
 		texts:    texts,
 	}
 }
 
+// trimMention removes the bot's user name that group chats append to a
+// command, e.g. "/start@tasks_bot", so it is routed like in private chats.
+func (r *Reader) trimMention(text string) string {
+	command, rest, _ := strings.Cut(text, " ")
+	if !strings.HasPrefix(command, "/") {
+		return text
+	}
+
+	trimmed, ok := strings.CutSuffix(command, "@"+r.bot.Self.UserName)
+	if !ok {
+		return text
+	}
+
+	if rest == "" {
+		return trimmed
+	}
+
+	return trimmed + " " + rest
+}
+
+func (r *Reader) PublishMenu() error {
+	return r.menu.Publish()
+}
+
 func (r *Reader) ReadUpdates(updates tgbotapi.UpdatesChannel) {
 	for update := range updates {
 		go r.updateActions(update)
@@ -49,12 +83,16 @@ func (r *Reader) ReadUpdates(updates tgbotapi.UpdatesChannel) {
 
 func (r *Reader) updateActions(update tgbotapi.Update) {
 	if update.Message != nil {
+		update.Message.Text = r.trimMention(update.Message.Text)
 		if strings.Contains(update.Message.Text, "new_team_user_") {
-			teamID, err := strconv.Atoi(strings.ReplaceAll(update.Message.Text, "/start new_team_user_", ""))
+			team, invite, _ := strings.Cut(strings.ReplaceAll(update.Message.Text, "/start new_team_user_", ""), "_")
+			teamID, err := strconv.Atoi(team)
 			if err != nil {
 				return
 			}
 			s := setMessageTeamSituation(update.Message, teamID)
+			s.Args = []string{invite}
+			r.setTimeZone(s)
 
 			handler := r.msg.GetHandler("/add_user_team")
 			if handler != nil {
@@ -69,6 +107,7 @@ func (r *Reader) updateActions(update tgbotapi.Update) {
 			return
 		}
 		s := setMessageSituation(update.Message)
+		r.setTimeZone(s)
 
 		handler := r.msg.GetHandler(update.Message.Text)
 		if handler != nil {
@@ -80,6 +119,37 @@ func (r *Reader) updateActions(update tgbotapi.Update) {
 			return
 		}
 
+		if fields := strings.Fields(update.Message.Text); len(fields) > 1 && strings.HasPrefix(fields[0], "/") {
+			handler = r.msg.GetHandler(fields[0])
+			if handler != nil {
+				s.Args = fields[1:]
+				err := handler(s)
+				if err != nil {
+					r.logger.Error("failed to get handler", zap.Error(err))
+				}
+
+				return
+			}
+		}
+
+		if taskID, ok := utils.CardTaskID(update.Message.ReplyToMessage); ok {
+			reply := "/comment_reply"
+			if utils.MessageAttachment(update.Message) != nil {
+				reply = "/attachment_reply"
+			}
+
+			handler = r.msg.GetHandler(reply)
+			if handler != nil {
+				s.Args = []string{strconv.Itoa(taskID)}
+				err := handler(s)
+				if err != nil {
+					r.logger.Error("failed to get handler", zap.Error(err))
+				}
+
+				return
+			}
+		}
+
 		path := rdb.GetPath(r.logger, r.rdb, s.Message.Chat.ID)
 
 		handler = r.msg.GetHandler(path)
@@ -114,9 +184,22 @@ func (r *Reader) updateActions(update tgbotapi.Update) {
 
 	if update.CallbackQuery != nil {
 		s := setCallbackSituation(update.CallbackQuery)
+		r.setTimeZone(s)
+
+		_, err := r.bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
+		if err != nil {
+			r.logger.Error("failed to answer callback", zap.Error(err))
+		}
+
+		command, args := utils.ParseCallbackData(update.CallbackQuery.Data)
+		s.Args = args
+
+		handler := r.callback.GetHandler(command)
+		if handler == nil {
+			return
+		}
 
-		handler := r.callback.GetHandler(update.CallbackQuery.Data)
-		err := handler(s)
+		err = handler(s)
 		if err != nil {
 			r.logger.Error("failed to get handler", zap.Error(err))
 		}
@@ -142,6 +225,16 @@ func (r *Reader) GetFromCommands(text string) (string, error) {
 	return "", nil
 }
 
+// setTimeZone loads the time zone deadlines are shown to the user in.
+func (r *Reader) setTimeZone(s *model.Situation) {
+	tz, err := r.repo.GetUserTimeZone(s.User.ID)
+	if err != nil {
+		r.logger.Error("failed to get user time zone", zap.Error(err))
+	}
+
+	s.User.TimeZone = tz
+}
+
 func setMessageSituation(message *tgbotapi.Message) *model.Situation {
 	return &model.Situation{
 		Message: message,
@@ -164,18 +257,21 @@ func setCallbackSituation(callback *tgbotapi.CallbackQuery) *model.Situation {
 	}
 }
 
-func newMessagesHandler(srv *message.Service) *MessageHandlers {
+func newMessagesHandler(srv *message.Service, ms *menu.Service, guard *Guard) *MessageHandlers {
 	handle := MessageHandlers{
 		Handlers: map[string]model.Handler{},
+		menu:     ms,
+		guard:    guard,
 	}
 
 	handle.Init(srv)
 	return &handle
 }
 
-func newCallbackHandler(srv *callback.Service) *CallBackHandlers {
+func newCallbackHandler(srv *callback.Service, guard *Guard) *CallBackHandlers {
 	handle := CallBackHandlers{
 		Handlers: map[string]model.Handler{},
+		guard:    guard,
 	}
 
 	handle.Init(srv)
@@ -0,0 +1,18 @@
+package model
+
+const (
+	AssignManual      = "manual"
+	AssignRoundRobin  = "round_robin"
+	AssignLeastLoaded = "least_loaded"
+)
+
+// AssignPolicies lists the assignment policies a team can choose from.
+var AssignPolicies = []string{AssignManual, AssignRoundRobin, AssignLeastLoaded}
+
+// MemberLoad is a team member with the sum of complexity of their open tasks.
+type MemberLoad struct {
+	UserID    int64
+	Login     string
+	Available bool
+	Load      int
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const (
+	AttachmentDocument = "document"
+	AttachmentPhoto    = "photo"
+	AttachmentVoice    = "voice"
+)
+
+type Attachment struct {
+	ID           int
+	TaskID       int
+	UserID       int64
+	Kind         string
+	FileID       string
+	FileUniqueID string
+	FileName     string
+	MimeType     string
+	FileSize     int
+	Duration     int
+	CreatedAt    time.Time
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditInsert = "insert"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEntry is a write to a table of the bot. Before and After hold the row
+// as JSON, Before is null for inserts and After for deletes.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	ActorID    int64           `json:"actor_id,omitempty"`
+	ActorLogin string          `json:"actor_login,omitempty"`
+	Action     string          `json:"action"`
+	Target     string          `json:"target"`
+	TargetID   string          `json:"target_id,omitempty"`
+	TaskID     int             `json:"task_id,omitempty"`
+	TeamID     int             `json:"team_id,omitempty"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditQuery selects audit entries, newest first. Zero fields match everything.
+type AuditQuery struct {
+	TeamID   int
+	TaskID   int
+	ActorID  int64
+	Target   string
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type Comment struct {
+	ID        int
+	TaskID    int
+	UserID    int64
+	Login     string
+	Text      string
+	CreatedAt time.Time
+}
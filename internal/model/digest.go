@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Digest is the morning summary of the tasks of a user.
+type Digest struct {
+	Overdue  []*Tasks
+	Today    []*Tasks
+	Assigned []*Tasks
+}
+
+// Empty reports whether the digest has no tasks, so it is not sent.
+func (d *Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.Today) == 0 && len(d.Assigned) == 0
+}
+
+// DigestUser is a user whose digest is due, with the time the previous one was
+// sent, zero before the first one.
+type DigestUser struct {
+	UserID   int64
+	TimeZone string
+	LastSent time.Time
+}
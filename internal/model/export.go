@@ -0,0 +1,16 @@
+package model
+
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+
+	ExportMine   = "mine"
+	ExportIssued = "issued"
+	ExportTeam   = "team"
+)
+
+// ExportFormats lists the file formats tasks can be exported to.
+var ExportFormats = []string{ExportCSV, ExportJSON}
+
+// ExportScopes lists the scopes offered by the export keyboard.
+var ExportScopes = []string{ExportMine, ExportIssued, ExportTeam}
@@ -0,0 +1,39 @@
+package model
+
+const (
+	FilterAll = "all"
+
+	DueOverdue = "overdue"
+	DueToday   = "today"
+	DueWeek    = "week"
+
+	SortDeadline   = "deadline"
+	SortComplexity = "complexity"
+	SortCreated    = "created"
+	SortPressing   = "pressing"
+)
+
+type TaskFilter struct {
+	Status        string `json:"status,omitempty"`
+	Due           string `json:"due,omitempty"`
+	MinComplexity int    `json:"min_complexity,omitempty"`
+	MaxComplexity int    `json:"max_complexity,omitempty"`
+	AssigneeID    int64  `json:"assignee_id,omitempty"`
+	CreatorID     int64  `json:"creator_id,omitempty"`
+	TeamID        int    `json:"team_id,omitempty"`
+	Label         string `json:"label,omitempty"`
+	// From and To limit deadlines to calendar days "2006-01-02" in the time
+	// zone of the user, both inclusive.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	Sort string `json:"sort,omitempty"`
+}
+
+// DefaultTaskFilter returns open tasks assigned to userID ordered by deadline.
+func DefaultTaskFilter(userID int64) *TaskFilter {
+	return &TaskFilter{
+		Status:     TaskOpen,
+		AssigneeID: userID,
+		Sort:       SortDeadline,
+	}
+}
@@ -11,4 +11,5 @@ type Situation struct {
 	CallbackQuery *tgbotapi.CallbackQuery `json:"callback_query,omitempty"`
 	User          *User                   `json:"user,omitempty"`
 	TeamID        int
+	Args          []string
 }
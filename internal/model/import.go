@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ImportRow is a valid row of an uploaded CSV that becomes an open task.
+type ImportRow struct {
+	Line        int       `json:"line"`
+	AssigneeID  int64     `json:"assignee_id"`
+	Assignee    string    `json:"assignee"`
+	Complexity  int       `json:"complexity"`
+	Deadline    time.Time `json:"deadline"`
+	Description string    `json:"description"`
+}
+
+// ImportError is a row of an uploaded CSV that cannot be imported. Reason is
+// the key of the text explaining why, formatted with Value when it is set.
+type ImportError struct {
+	Line   int
+	Reason string
+	Value  string
+}
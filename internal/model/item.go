@@ -0,0 +1,10 @@
+package model
+
+// TaskItem is an entry of the checklist of a task.
+type TaskItem struct {
+	ID       int
+	TaskID   int
+	Position int
+	Text     string
+	Done     bool
+}
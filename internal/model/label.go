@@ -0,0 +1,8 @@
+package model
+
+// Label is an entry of the label vocabulary of a team.
+type Label struct {
+	ID     int
+	TeamID int
+	Name   string
+}
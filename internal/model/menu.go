@@ -0,0 +1,18 @@
+package model
+
+type MenuScope int
+
+const (
+	MenuPrivate MenuScope = 1 << iota
+	MenuGroup
+	MenuTeamAdmin
+)
+
+type MenuCommand struct {
+	Command string
+	Scopes  MenuScope
+}
+
+func (c *MenuCommand) In(scope MenuScope) bool {
+	return c.Scopes&scope != 0
+}
@@ -0,0 +1,18 @@
+package model
+
+// Actor is what the authorization policy knows about the user asking for
+// something. TeamID is 0 and Role empty outside of a team.
+type Actor struct {
+	ID         int64
+	Registered bool
+	TeamID     int
+	Role       string
+}
+
+// TaskParties are the users a task belongs to. Deleted tasks are in the trash.
+type TaskParties struct {
+	UserID    int64
+	CreatorID int64
+	TeamID    int
+	Deleted   bool
+}
@@ -0,0 +1,11 @@
+package model
+
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists the task priorities from the lowest to the highest.
+var Priorities = []string{PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent}
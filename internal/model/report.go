@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// MemberReport is the workload of a team member over the last week, with the
+// same figures for the week before.
+type MemberReport struct {
+	UserID int64
+	Login  string
+	Week   WorkloadWeek
+	Prev   WorkloadWeek
+}
+
+type WorkloadWeek struct {
+	Done       int
+	Overdue    int
+	OpenPoints int
+	// Lateness is the average delay of the tasks done after their deadline,
+	// tasks done in time counting as zero.
+	Lateness time.Duration
+}
+
+// ReportSchedule is when the weekly report of a team is sent to its admins.
+// Day is an ISO weekday, 1 for Monday; 0 turns the report off.
+type ReportSchedule struct {
+	TeamID   int
+	Day      int
+	Time     string
+	TimeZone string
+}
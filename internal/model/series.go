@@ -0,0 +1,15 @@
+package model
+
+const (
+	SeriesActive = "active"
+	SeriesPaused = "paused"
+	SeriesEnded  = "ended"
+)
+
+type Series struct {
+	ID        int
+	CreatorID int64
+	Rule      string
+	Status    string
+	TimeZone  string
+}
@@ -2,10 +2,47 @@ package model
 
 import "time"
 
+const (
+	TaskDraft = "draft"
+	TaskOpen  = "open"
+	TaskDone  = "done"
+)
+
 type Tasks struct {
 	ID          int
 	UserID      int64
+	CreatorID   int64
+	TeamID      int
+	SeriesID    int
+	Status      string
 	Complexity  int
+	Priority    string
 	Deadline    time.Time
 	Description string
+	Attachments int
+	Items       int
+	ItemsDone   int
+	BlockedBy   []int
+	Labels      []string
+}
+
+const (
+	FieldComplexity  = "complexity"
+	FieldDeadline    = "deadline"
+	FieldDescription = "description"
+	FieldPriority    = "priority"
+)
+
+type TaskChange struct {
+	TaskID int
+	UserID int64
+	Field  string
+	Before string
+	After  string
+}
+
+type SearchResult struct {
+	Task     *Tasks
+	Headline string
+	Rank     float64
 }
@@ -1,5 +1,10 @@
 package model
 
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
 type Team struct {
 	Name  string
 	Users []*User
@@ -1,9 +1,24 @@
 package model
 
+import "time"
+
+const DefaultTimeZone = "UTC"
+
 type User struct {
 	ID         int64
 	Login      string
 	Password   string
 	TgName     string
 	TgUsername string
+	TimeZone   string
+}
+
+// Location returns the time zone of the user, UTC when it is unset or unknown.
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil || u.TimeZone == "" {
+		return time.UTC
+	}
+
+	return loc
 }
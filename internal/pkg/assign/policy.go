@@ -0,0 +1,51 @@
+package assign
+
+import (
+	"cmp"
+	"slices"
+
+	"tgbot/internal/model"
+)
+
+// Suggest picks the assignee of a new team task by policy among the available
+// members, ordered by user ID. Round-robin takes the member after lastID,
+// least-loaded the one with the lowest load. It returns nil for the manual
+// policy or when nobody is available.
+func Suggest(policy string, members []*model.MemberLoad, lastID int64) *model.MemberLoad {
+	available := make([]*model.MemberLoad, 0, len(members))
+	for _, member := range members {
+		if member.Available {
+			available = append(available, member)
+		}
+	}
+
+	if len(available) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(available, func(a, b *model.MemberLoad) int {
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+
+	switch policy {
+	case model.AssignRoundRobin:
+		for _, member := range available {
+			if member.UserID > lastID {
+				return member
+			}
+		}
+
+		return available[0]
+	case model.AssignLeastLoaded:
+		least := available[0]
+		for _, member := range available[1:] {
+			if member.Load < least.Load {
+				least = member
+			}
+		}
+
+		return least
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewToken returns a random URL-safe secret of 32 bytes.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// NewInviteToken returns a random secret of 16 bytes, short enough for the
+// start parameter of a Telegram deep link.
+func NewInviteToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,281 @@
+package deadline
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnrecognized = errors.New("deadline not recognized")
+	ErrPast         = errors.New("deadline is in the past")
+)
+
+// endOfDay is the time of day used when only a day is given.
+const endOfDayHour, endOfDayMinute = 23, 59
+
+var (
+	relativeRe   = regexp.MustCompile(`^(?:in |через )?((?:\d+(?:[.,]\d+)?\s*\p{L}+\s*)+)$`)
+	amountRe     = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(\p{L}+)`)
+	singleRe     = regexp.MustCompile(`^(?:in an? |in one |через )(\p{L}+)$`)
+	timeRe       = regexp.MustCompile(`^(?:at |в )?(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	isoDateRe    = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})(?:[ t](.+))?$`)
+	dottedDateRe = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{4}))?(?: (.+))?$`)
+)
+
+var units = map[string]time.Duration{}
+
+func init() {
+	add := func(d time.Duration, names ...string) {
+		for _, name := range names {
+			units[name] = d
+		}
+	}
+
+	add(time.Minute, "m", "min", "mins", "minute", "minutes", "м", "мин", "минута", "минуту", "минуты", "минут")
+	add(time.Hour, "h", "hr", "hrs", "hour", "hours", "ч", "час", "часа", "часов")
+	add(24*time.Hour, "d", "day", "days", "д", "дн", "день", "дня", "дней")
+	add(7*24*time.Hour, "w", "wk", "week", "weeks", "н", "нед", "неделя", "неделю", "недели", "недель")
+}
+
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday, "понедельник": time.Monday, "пн": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "вторник": time.Tuesday, "вт": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "четверг": time.Thursday, "чт": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday, "воскресенье": time.Sunday, "вс": time.Sunday,
+}
+
+var dayOffsets = map[string]int{
+	"today": 0, "сегодня": 0,
+	"tomorrow": 1, "завтра": 1,
+	"day after tomorrow": 2, "послезавтра": 2,
+}
+
+var periodEnds = map[string]func(now time.Time) time.Time{
+	"end of day": endOfToday, "eod": endOfToday, "конец дня": endOfToday, "до конца дня": endOfToday,
+	"end of week": endOfWeek, "eow": endOfWeek, "конец недели": endOfWeek, "до конца недели": endOfWeek,
+	"end of month": endOfMonth, "eom": endOfMonth, "конец месяца": endOfMonth, "до конца месяца": endOfMonth,
+}
+
+var dayPrefixes = []string{"next ", "on ", "this ", "в следующий ", "в следующую ", "в следующее ", "следующий ", "следующую ", "во ", "в "}
+
+// Parse reads a deadline in ru or en relative to now. It accepts durations
+// ("3h", "1.5h", "2d 4h", "через 2 дня", "in a week"), day names with an
+// optional time ("tomorrow 18:00", "пятница", "next monday at 9am"), dates
+// ("2026-11-01", "01.11.2026 18:00", "1.11"), a time of day ("18:00") and
+// period ends ("end of week", "конец месяца"). Dates and times are read in
+// the location of now.
+func Parse(text string, now time.Time) (time.Time, error) {
+	s := normalize(text)
+	if s == "" {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	t, ok := parse(s, now)
+	if !ok {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	if !t.After(now) {
+		return time.Time{}, ErrPast
+	}
+
+	return t, nil
+}
+
+func parse(s string, now time.Time) (time.Time, bool) {
+	if end, ok := periodEnds[s]; ok {
+		return end(now), true
+	}
+
+	if d, ok := parseDuration(s); ok {
+		return now.Add(d), true
+	}
+
+	if t, ok := parseDay(s, now); ok {
+		return t, true
+	}
+
+	if t, ok := parseDate(s, now); ok {
+		return t, true
+	}
+
+	if hour, minute, ok := parseClock(s); ok {
+		t := at(now, hour, minute)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+func normalize(text string) string {
+	s := strings.ToLower(strings.TrimSpace(text))
+	s = strings.ReplaceAll(s, "ё", "е")
+	s = strings.TrimSuffix(s, ".")
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func parseDuration(s string) (time.Duration, bool) {
+	if m := singleRe.FindStringSubmatch(s); m != nil {
+		d, ok := units[m[1]]
+		return d, ok
+	}
+
+	m := relativeRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	var total time.Duration
+	for _, part := range amountRe.FindAllStringSubmatch(m[1], -1) {
+		unit, ok := units[part[2]]
+		if !ok {
+			return 0, false
+		}
+
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(part[1], ",", "."), 64)
+		if err != nil {
+			return 0, false
+		}
+
+		total += time.Duration(amount * float64(unit))
+	}
+
+	return total, total > 0
+}
+
+// parseDay reads a day name or weekday followed by an optional time of day.
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	for _, prefix := range dayPrefixes {
+		s = strings.TrimPrefix(s, prefix)
+	}
+
+	day, clock := s, ""
+	offset, ok := -1, false
+	for name, o := range dayOffsets {
+		if s == name || strings.HasPrefix(s, name+" ") {
+			day, clock, offset = name, strings.TrimSpace(strings.TrimPrefix(s, name)), o
+			break
+		}
+	}
+
+	if offset < 0 {
+		day, clock, _ = strings.Cut(s, " ")
+		var wd time.Weekday
+		wd, ok = weekdays[day]
+		if !ok {
+			return time.Time{}, false
+		}
+
+		offset = (int(wd) - int(now.Weekday()) + 7) % 7
+		if offset == 0 {
+			offset = 7
+		}
+	}
+
+	hour, minute := endOfDayHour, endOfDayMinute
+	if clock != "" {
+		hour, minute, ok = parseClock(clock)
+		if !ok {
+			return time.Time{}, false
+		}
+	}
+
+	return at(now.AddDate(0, 0, offset), hour, minute), true
+}
+
+func parseDate(s string, now time.Time) (time.Time, bool) {
+	var year, month, day int
+	var clock string
+	yearGiven := true
+
+	if m := isoDateRe.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		day, _ = strconv.Atoi(m[3])
+		clock = m[4]
+	} else if m = dottedDateRe.FindStringSubmatch(s); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		year, clock = now.Year(), m[4]
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+		} else {
+			yearGiven = false
+		}
+	} else {
+		return time.Time{}, false
+	}
+
+	hour, minute := endOfDayHour, endOfDayMinute
+	if clock != "" {
+		var ok bool
+		hour, minute, ok = parseClock(clock)
+		if !ok {
+			return time.Time{}, false
+		}
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, 0, 0, now.Location())
+	if t.Day() != day || int(t.Month()) != month {
+		return time.Time{}, false
+	}
+
+	if !yearGiven && !t.After(now) {
+		t = t.AddDate(1, 0, 0)
+	}
+
+	return t, true
+}
+
+func parseClock(s string) (int, int, bool) {
+	m := timeRe.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	if m[3] != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+func at(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+func endOfToday(now time.Time) time.Time {
+	return at(now, endOfDayHour, endOfDayMinute)
+}
+
+func endOfWeek(now time.Time) time.Time {
+	return at(now.AddDate(0, 0, (7-int(now.Weekday()))%7), endOfDayHour, endOfDayMinute)
+}
+
+func endOfMonth(now time.Time) time.Time {
+	return at(time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()), endOfDayHour, endOfDayMinute)
+}
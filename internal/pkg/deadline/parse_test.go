@@ -0,0 +1,112 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, berlin)
+	}
+
+	// A Friday two days before summer time ends in Berlin.
+	friday := date(2026, time.October, 23, 14, 30)
+	// The last day of a month that is not followed by a leap day.
+	monthEnd := date(2026, time.January, 31, 10, 0)
+	// The day before summer time starts in Berlin.
+	springSaturday := date(2026, time.March, 28, 10, 0)
+	leapYear := date(2028, time.February, 28, 10, 0)
+
+	tests := []struct {
+		text string
+		now  time.Time
+		want time.Time
+		err  error
+	}{
+		{text: "3h", now: friday, want: date(2026, time.October, 23, 17, 30)},
+		{text: "1.5h", now: friday, want: date(2026, time.October, 23, 16, 0)},
+		{text: "1,5 часа", now: friday, want: date(2026, time.October, 23, 16, 0)},
+		{text: "45 min", now: friday, want: date(2026, time.October, 23, 15, 15)},
+		{text: "in an hour", now: friday, want: date(2026, time.October, 23, 15, 30)},
+		// Durations are absolute, so the hour given back on Sunday shows on the clock.
+		{text: "2d 4h", now: friday, want: date(2026, time.October, 25, 17, 30)},
+		{text: "через 2 дня", now: friday, want: date(2026, time.October, 25, 13, 30)},
+		{text: "in a week", now: friday, want: date(2026, time.October, 30, 13, 30)},
+		{text: "через неделю", now: friday, want: date(2026, time.October, 30, 13, 30)},
+
+		{text: "today", now: friday, want: date(2026, time.October, 23, 23, 59)},
+		{text: "tomorrow 18:00", now: friday, want: date(2026, time.October, 24, 18, 0)},
+		{text: "Завтра", now: friday, want: date(2026, time.October, 24, 23, 59)},
+		// Days keep the time of day across the change of summer time.
+		{text: "послезавтра в 9:00", now: friday, want: date(2026, time.October, 25, 9, 0)},
+		{text: "day after tomorrow at 7pm", now: friday, want: date(2026, time.October, 25, 19, 0)},
+		{text: "sunday", now: friday, want: date(2026, time.October, 25, 23, 59)},
+		{text: "next monday at 9am", now: friday, want: date(2026, time.October, 26, 9, 0)},
+		{text: "on wed 12:30", now: friday, want: date(2026, time.October, 28, 12, 30)},
+		{text: "пятница", now: friday, want: date(2026, time.October, 30, 23, 59)},
+		{text: "в пятницу 18:00", now: friday, want: date(2026, time.October, 30, 18, 0)},
+		{text: "в следующий вторник", now: friday, want: date(2026, time.October, 27, 23, 59)},
+
+		{text: "2026-11-01", now: friday, want: date(2026, time.November, 1, 23, 59)},
+		{text: "2026-11-01 08:15", now: friday, want: date(2026, time.November, 1, 8, 15)},
+		{text: "01.11.2026 18:00", now: friday, want: date(2026, time.November, 1, 18, 0)},
+		{text: "1.11", now: friday, want: date(2026, time.November, 1, 23, 59)},
+		{text: "20.10", now: friday, want: date(2027, time.October, 20, 23, 59)},
+
+		{text: "18:00", now: friday, want: date(2026, time.October, 23, 18, 0)},
+		{text: "9:00", now: friday, want: date(2026, time.October, 24, 9, 0)},
+		{text: "at 11pm", now: friday, want: date(2026, time.October, 23, 23, 0)},
+
+		{text: "eod", now: friday, want: date(2026, time.October, 23, 23, 59)},
+		{text: "end of week", now: friday, want: date(2026, time.October, 25, 23, 59)},
+		{text: "до конца недели", now: friday, want: date(2026, time.October, 25, 23, 59)},
+		{text: "конец месяца", now: friday, want: date(2026, time.October, 31, 23, 59)},
+
+		{text: "end of month", now: monthEnd, want: date(2026, time.January, 31, 23, 59)},
+		{text: "1d", now: monthEnd, want: date(2026, time.February, 1, 10, 0)},
+		{text: "tomorrow", now: monthEnd, want: date(2026, time.February, 1, 23, 59)},
+		{text: "31.02", now: monthEnd, err: ErrUnrecognized},
+		{text: "29.02", now: monthEnd, err: ErrUnrecognized},
+		{text: "29.02", now: leapYear, want: date(2028, time.February, 29, 23, 59)},
+		{text: "end of month", now: leapYear, want: date(2028, time.February, 29, 23, 59)},
+
+		// The night of Sunday is an hour shorter when summer time starts.
+		{text: "tomorrow 10:00", now: springSaturday, want: date(2026, time.March, 29, 10, 0)},
+		{text: "1d", now: springSaturday, want: date(2026, time.March, 29, 11, 0)},
+
+		{text: "", now: friday, err: ErrUnrecognized},
+		{text: "someday", now: friday, err: ErrUnrecognized},
+		{text: "3 parsecs", now: friday, err: ErrUnrecognized},
+		{text: "25:00", now: friday, err: ErrUnrecognized},
+		{text: "13pm", now: friday, err: ErrUnrecognized},
+		{text: "2026-13-01", now: friday, err: ErrUnrecognized},
+		{text: "2026-10-01", now: friday, err: ErrPast},
+		{text: "0h", now: friday, err: ErrUnrecognized},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.text, tt.now)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q, %v) error = %v, want %v", tt.text, tt.now, err, tt.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Parse(%q, %v) error = %v", tt.text, tt.now, err)
+			continue
+		}
+
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q, %v) = %v, want %v", tt.text, tt.now, got, tt.want)
+		}
+	}
+}
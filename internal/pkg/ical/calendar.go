@@ -0,0 +1,124 @@
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tgbot/internal/model"
+)
+
+const (
+	// eventLength is the length of the event ending at a deadline.
+	eventLength = 30 * time.Minute
+	// alarmBefore is how long before the deadline the calendar reminds of it.
+	alarmBefore = time.Hour
+
+	// uidDomain makes the UIDs of task events unique among other calendars.
+	uidDomain = "tasks.tgbot"
+
+	lineLimit   = 75
+	stampLayout = "20060102T150405Z"
+)
+
+// FeedPath is followed by the secret of a calendar feed and ".ics".
+const FeedPath = "/calendar/"
+
+// FeedURL returns the address of the calendar feed with the given secret.
+func FeedURL(publicURL, token string) string {
+	return strings.TrimSuffix(publicURL, "/") + FeedPath + token + ".ics"
+}
+
+// priorities maps task priorities to the iCalendar scale where 1 is the highest.
+var priorities = map[string]int{
+	model.PriorityUrgent: 1,
+	model.PriorityHigh:   3,
+	model.PriorityNormal: 5,
+	model.PriorityLow:    9,
+}
+
+// Calendar renders the tasks as an iCalendar with an event ending at the
+// deadline of each task. Events keep the UID of their task, so calendar apps
+// replace them on updates instead of adding duplicates.
+func Calendar(name string, tasks []*model.Tasks, now time.Time) []byte {
+	var buf bytes.Buffer
+	line := func(parts ...string) {
+		writeLine(&buf, strings.Join(parts, ""))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//tgbot//tasks//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:", escape(name))
+
+	for _, task := range tasks {
+		if task.Deadline.IsZero() {
+			continue
+		}
+
+		summary := "#" + strconv.Itoa(task.ID) + " " + firstLine(task.Description)
+
+		line("BEGIN:VEVENT")
+		line("UID:task-", strconv.Itoa(task.ID), "@", uidDomain)
+		line("DTSTAMP:", now.UTC().Format(stampLayout))
+		line("DTSTART:", task.Deadline.Add(-eventLength).UTC().Format(stampLayout))
+		line("DTEND:", task.Deadline.UTC().Format(stampLayout))
+		line("SUMMARY:", escape(summary))
+		line("DESCRIPTION:", escape(task.Description))
+		if p, ok := priorities[task.Priority]; ok {
+			line("PRIORITY:", strconv.Itoa(p))
+		}
+		if len(task.Labels) > 0 {
+			labels := make([]string, 0, len(task.Labels))
+			for _, label := range task.Labels {
+				labels = append(labels, escape(label))
+			}
+			line("CATEGORIES:", strings.Join(labels, ","))
+		}
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:", escape(summary))
+		line("TRIGGER;RELATED=END:-PT", strconv.Itoa(int(alarmBefore.Minutes())), "M")
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(first)
+}
+
+// escape escapes a text value as RFC 5545 requires.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writeLine writes a content line folded at 75 octets without splitting a
+// UTF-8 character.
+func writeLine(buf *bytes.Buffer, s string) {
+	limit := lineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = lineLimit - 1
+	}
+
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
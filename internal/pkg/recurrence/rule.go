@@ -0,0 +1,264 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+const untilLayout = "20060102"
+
+var ErrInvalidRule = errors.New("recurrence rule not recognized")
+
+// Rule is the supported subset of an iCalendar RRULE: FREQ, INTERVAL, BYDAY
+// for weekly rules, BYMONTHDAY for monthly rules and UNTIL.
+type Rule struct {
+	Freq     string
+	Interval int
+	Weekdays []time.Weekday
+	MonthDay int
+	Until    time.Time
+}
+
+var dayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var dayNames = map[string]time.Weekday{
+	"su": time.Sunday, "sun": time.Sunday, "sunday": time.Sunday, "вс": time.Sunday, "воскресенье": time.Sunday,
+	"mo": time.Monday, "mon": time.Monday, "monday": time.Monday, "пн": time.Monday, "понедельник": time.Monday,
+	"tu": time.Tuesday, "tue": time.Tuesday, "tuesday": time.Tuesday, "вт": time.Tuesday, "вторник": time.Tuesday,
+	"we": time.Wednesday, "wed": time.Wednesday, "wednesday": time.Wednesday, "ср": time.Wednesday, "среда": time.Wednesday,
+	"th": time.Thursday, "thu": time.Thursday, "thursday": time.Thursday, "чт": time.Thursday, "четверг": time.Thursday,
+	"fr": time.Friday, "fri": time.Friday, "friday": time.Friday, "пт": time.Friday, "пятница": time.Friday,
+	"sa": time.Saturday, "sat": time.Saturday, "saturday": time.Saturday, "сб": time.Saturday, "суббота": time.Saturday,
+}
+
+var aliases = map[string]string{
+	"daily": Daily, "ежедневно": Daily, "каждый день": Daily,
+	"weekly": Weekly, "еженедельно": Weekly, "каждую неделю": Weekly,
+	"monthly": Monthly, "ежемесячно": Monthly, "каждый месяц": Monthly,
+}
+
+var weekdayAliases = []string{"weekdays", "по будням", "будни"}
+
+// Parse reads "daily", "weekdays", "weekly [days]", "monthly [day]", their
+// ru equivalents or an RRULE like "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+// first is the deadline of the first instance; it fills the weekday of a bare
+// weekly rule and the day of a bare monthly rule.
+func Parse(text string, first time.Time) (*Rule, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(strings.TrimSpace(text))), " ")
+	s = strings.TrimPrefix(s, "rrule:")
+
+	var (
+		r   *Rule
+		err error
+	)
+	if strings.Contains(s, "freq=") {
+		r, err = parseRRule(s)
+	} else {
+		r, err = parseAlias(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if r.Freq == Weekly && len(r.Weekdays) == 0 {
+		r.Weekdays = []time.Weekday{first.Weekday()}
+	}
+	if r.Freq == Monthly && r.MonthDay == 0 {
+		r.MonthDay = first.Day()
+	}
+
+	return r, nil
+}
+
+func parseAlias(s string) (*Rule, error) {
+	if slices.Contains(weekdayAliases, s) {
+		return &Rule{Freq: Weekly, Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}, nil
+	}
+
+	for alias, freq := range aliases {
+		if s != alias && !strings.HasPrefix(s, alias+" ") {
+			continue
+		}
+
+		r := &Rule{Freq: freq}
+		rest := strings.TrimSpace(strings.TrimPrefix(s, alias))
+		if rest == "" {
+			return r, nil
+		}
+
+		switch freq {
+		case Weekly:
+			days, err := parseDays(strings.FieldsFunc(rest, func(c rune) bool { return c == ',' || c == ' ' }))
+			if err != nil {
+				return nil, err
+			}
+			r.Weekdays = days
+		case Monthly:
+			day, err := strconv.Atoi(rest)
+			if err != nil || day < 1 || day > 31 {
+				return nil, ErrInvalidRule
+			}
+			r.MonthDay = day
+		default:
+			return nil, ErrInvalidRule
+		}
+
+		return r, nil
+	}
+
+	return nil, ErrInvalidRule
+}
+
+func parseRRule(s string) (*Rule, error) {
+	r := &Rule{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, ErrInvalidRule
+		}
+
+		var err error
+		switch key {
+		case "freq":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				return nil, fmt.Errorf("unsupported frequency %q: %w", value, ErrInvalidRule)
+			}
+		case "interval":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = ErrInvalidRule
+			}
+		case "byday":
+			r.Weekdays, err = parseDays(strings.Split(value, ","))
+		case "bymonthday":
+			r.MonthDay, err = strconv.Atoi(value)
+			if err == nil && (r.MonthDay < 1 || r.MonthDay > 31) {
+				err = ErrInvalidRule
+			}
+		case "until":
+			r.Until, err = time.Parse(untilLayout, value[:min(len(value), len(untilLayout))])
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q: %w", key, ErrInvalidRule)
+		}
+		if err != nil {
+			return nil, ErrInvalidRule
+		}
+	}
+
+	if r.Freq == "" || (len(r.Weekdays) > 0 && r.Freq != Weekly) || (r.MonthDay > 0 && r.Freq != Monthly) {
+		return nil, ErrInvalidRule
+	}
+
+	return r, nil
+}
+
+// ParseWeekday reads a weekday name or abbreviation in en or ru, e.g. "mon" or "пн".
+func ParseWeekday(name string) (time.Weekday, bool) {
+	day, ok := dayNames[strings.ToLower(strings.TrimSpace(name))]
+	return day, ok
+}
+
+func parseDays(names []string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range names {
+		day, ok := dayNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, ErrInvalidRule
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+
+	slices.Sort(days)
+	return days, nil
+}
+
+// Next returns the first occurrence strictly after after, keeping the time of
+// day of after. The interval of weekly rules counts weeks from after. A zero
+// time means the rule has ended.
+func (r *Rule) Next(after time.Time) time.Time {
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = after.AddDate(0, 0, r.Interval)
+	case Weekly:
+		next = r.nextWeekly(after)
+	case Monthly:
+		next = r.nextMonthly(after)
+	}
+
+	if !r.Until.IsZero() && next.After(time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 23, 59, 59, 0, after.Location())) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// nextWeekly returns the next listed weekday later in the week of after
+// (weeks start on Monday) or the first listed weekday Interval weeks later.
+func (r *Rule) nextWeekly(after time.Time) time.Time {
+	offset := (int(after.Weekday()) + 6) % 7
+	for i := 1; offset+i < 7; i++ {
+		day := after.AddDate(0, 0, i)
+		if slices.Contains(r.Weekdays, day.Weekday()) {
+			return day
+		}
+	}
+
+	weekStart := after.AddDate(0, 0, 7*r.Interval-offset)
+	for i := 0; i < 7; i++ {
+		day := weekStart.AddDate(0, 0, i)
+		if slices.Contains(r.Weekdays, day.Weekday()) {
+			return day
+		}
+	}
+
+	return time.Time{}
+}
+
+// nextMonthly returns MonthDay of the month Interval months after after,
+// clamped to the last day of shorter months.
+func (r *Rule) nextMonthly(after time.Time) time.Time {
+	month := time.Date(after.Year(), after.Month()+time.Month(r.Interval), 1, after.Hour(), after.Minute(), 0, 0, after.Location())
+	last := month.AddDate(0, 1, -1).Day()
+
+	return month.AddDate(0, 0, min(r.MonthDay, last)-1)
+}
+
+// String returns the rule as an RRULE value, the form it is stored in.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.Weekdays) > 0 {
+		codes := make([]string, 0, len(r.Weekdays))
+		for _, day := range r.Weekdays {
+			codes = append(codes, dayCodes[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.MonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.MonthDay))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,92 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, berlin)
+	}
+
+	// A Monday the week before summer time ends in Berlin.
+	monday := date(2026, time.October, 19, 9, 0)
+	thursday := date(2026, time.October, 22, 9, 0)
+	// Summer time ends on this Sunday.
+	sunday := date(2026, time.October, 25, 9, 0)
+	friday := date(2026, time.October, 23, 14, 30)
+	// The day before summer time starts in Berlin.
+	springSaturday := date(2026, time.March, 28, 10, 0)
+
+	tests := []struct {
+		rule  string
+		after time.Time
+		want  time.Time
+		err   error
+	}{
+		{rule: "daily", after: friday, want: date(2026, time.October, 24, 14, 30)},
+		{rule: "FREQ=DAILY;INTERVAL=3", after: friday, want: date(2026, time.October, 26, 14, 30)},
+		// Days keep the time of day across the change of summer time.
+		{rule: "daily", after: springSaturday, want: date(2026, time.March, 29, 10, 0)},
+
+		{rule: "weekly", after: friday, want: date(2026, time.October, 30, 14, 30)},
+		{rule: "weekdays", after: friday, want: date(2026, time.October, 26, 14, 30)},
+		{rule: "по будням", after: thursday, want: date(2026, time.October, 23, 9, 0)},
+		// The interval counts from the week of after, later days of it come first.
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", after: monday, want: thursday},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", after: thursday, want: date(2026, time.November, 2, 9, 0)},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", after: sunday, want: date(2026, time.November, 2, 9, 0)},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", after: date(2026, time.October, 24, 9, 0), want: sunday},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", after: sunday, want: date(2026, time.November, 8, 9, 0)},
+		{rule: "weekly пн,чт", after: friday, want: date(2026, time.October, 26, 14, 30)},
+
+		{rule: "monthly", after: date(2026, time.January, 31, 10, 0), want: date(2026, time.February, 28, 10, 0)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", after: date(2026, time.January, 31, 10, 0), want: date(2026, time.February, 28, 10, 0)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", after: date(2028, time.January, 31, 10, 0), want: date(2028, time.February, 29, 10, 0)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", after: date(2026, time.February, 28, 10, 0), want: date(2026, time.March, 31, 10, 0)},
+		{rule: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=31", after: date(2026, time.January, 31, 10, 0), want: date(2026, time.April, 30, 10, 0)},
+		{rule: "monthly 15", after: date(2026, time.December, 20, 10, 0), want: date(2027, time.January, 15, 10, 0)},
+
+		// UNTIL takes in the whole day.
+		{rule: "FREQ=DAILY;UNTIL=20261025", after: friday, want: date(2026, time.October, 24, 14, 30)},
+		{rule: "FREQ=DAILY;UNTIL=20261025", after: date(2026, time.October, 24, 23, 0), want: date(2026, time.October, 25, 23, 0)},
+		{rule: "FREQ=DAILY;UNTIL=20261025", after: sunday},
+		{rule: "FREQ=WEEKLY;BYDAY=MO;UNTIL=20261101T000000Z", after: date(2026, time.October, 30, 14, 30)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20260228", after: date(2026, time.January, 31, 10, 0), want: date(2026, time.February, 28, 10, 0)},
+
+		{rule: "", after: friday, err: ErrInvalidRule},
+		{rule: "yearly", after: friday, err: ErrInvalidRule},
+		{rule: "FREQ=YEARLY", after: friday, err: ErrInvalidRule},
+		{rule: "monthly 32", after: friday, err: ErrInvalidRule},
+		{rule: "FREQ=DAILY;BYDAY=MO", after: friday, err: ErrInvalidRule},
+		{rule: "FREQ=WEEKLY;INTERVAL=0", after: friday, err: ErrInvalidRule},
+		{rule: "weekly someday", after: friday, err: ErrInvalidRule},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule, tt.after)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.rule, err, tt.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.rule, err)
+			continue
+		}
+
+		got := rule.Next(tt.after)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%v) = %v, want %v", rule, tt.after, got, tt.want)
+		}
+	}
+}
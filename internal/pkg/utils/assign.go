@@ -0,0 +1,55 @@
+package utils
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// MemberLoads lists the team members with their open complexity points,
+// marking unavailable members and the suggested assignee.
+func MemberLoads(texts map[string]string, members []*model.MemberLoad, suggested *model.MemberLoad) string {
+	var text string
+	for _, member := range members {
+		line := GetFormatText(texts, "member_load", member.Login, member.UserID, member.Load)
+		if !member.Available {
+			line += " " + GetFormatText(texts, "member_unavailable")
+		}
+		if suggested != nil && member.UserID == suggested.UserID {
+			line = "✓ " + line
+		}
+
+		text += "\n" + line
+	}
+
+	return text
+}
+
+// AssignSuggestion renders the assignee suggested by the team policy with the
+// load of each member and a button accepting it.
+func AssignSuggestion(texts map[string]string, policy string, members []*model.MemberLoad, suggested *model.MemberLoad) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := GetFormatText(texts, "assign_loads", GetFormatText(texts, "assign_"+policy)) + "\n" + MemberLoads(texts, members, suggested)
+	if suggested == nil {
+		return text + "\n\n" + GetFormatText(texts, "assign_nobody"), tgbotapi.InlineKeyboardMarkup{}
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "assign_accept", suggested.Login), CallbackData("/assign_accept", suggested.UserID))))
+}
+
+// PolicyToggles returns a button for each assignment policy with the active one marked.
+func PolicyToggles(texts map[string]string, active string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, policy := range model.AssignPolicies {
+		label := GetFormatText(texts, "assign_"+policy)
+		if policy == active {
+			label = "✓ " + label
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, CallbackData("/assign_policy", policy))))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
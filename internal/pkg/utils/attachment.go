@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// MessageAttachment returns the document, photo or voice note of msg, or nil
+// when it has none. Of a photo the largest size is kept.
+func MessageAttachment(msg *tgbotapi.Message) *model.Attachment {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.Document != nil:
+		return &model.Attachment{
+			Kind:         model.AttachmentDocument,
+			FileID:       msg.Document.FileID,
+			FileUniqueID: msg.Document.FileUniqueID,
+			FileName:     msg.Document.FileName,
+			MimeType:     msg.Document.MimeType,
+			FileSize:     msg.Document.FileSize,
+		}
+	case len(msg.Photo) > 0:
+		photo := msg.Photo[len(msg.Photo)-1]
+		return &model.Attachment{
+			Kind:         model.AttachmentPhoto,
+			FileID:       photo.FileID,
+			FileUniqueID: photo.FileUniqueID,
+			MimeType:     "image/jpeg",
+			FileSize:     photo.FileSize,
+		}
+	case msg.Voice != nil:
+		return &model.Attachment{
+			Kind:         model.AttachmentVoice,
+			FileID:       msg.Voice.FileID,
+			FileUniqueID: msg.Voice.FileUniqueID,
+			MimeType:     msg.Voice.MimeType,
+			FileSize:     msg.Voice.FileSize,
+			Duration:     msg.Voice.Duration,
+		}
+	default:
+		return nil
+	}
+}
+
+// AttachmentName returns the file name of a document or a localized name of a
+// photo or voice note, followed by its size.
+func AttachmentName(texts map[string]string, attachment *model.Attachment) string {
+	name := attachment.FileName
+	switch {
+	case attachment.Kind == model.AttachmentVoice:
+		name = GetFormatText(texts, "attachment_voice", attachment.Duration/60, attachment.Duration%60)
+	case name == "":
+		name = GetFormatText(texts, "attachment_"+attachment.Kind)
+	}
+
+	if attachment.FileSize > 0 {
+		name += ", " + formatSize(attachment.FileSize)
+	}
+
+	return name
+}
+
+func formatSize(size int) string {
+	switch {
+	case size < 1<<10:
+		return strconv.Itoa(size) + " B"
+	case size < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	}
+}
+
+// AttachmentsPage renders one page of the attachments of a task with a button
+// re-sending each file. It is paged with "/card_attachments:<task>:<page>".
+func AttachmentsPage(texts map[string]string, taskID int, attachments []*model.Attachment, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	from, to, current, pages := PageBounds(len(attachments), size, page)
+
+	text := GetFormatText(texts, "attachments_title", taskID, len(attachments))
+	if len(attachments) == 0 {
+		text += "\n\n" + GetFormatText(texts, "no_attachments")
+	} else {
+		text += "\n" + GetFormatText(texts, "page_of", current+1, pages)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, attachment := range attachments[from:to] {
+		name := strconv.Itoa(from+i+1) + ". " + AttachmentName(texts, attachment)
+		text += "\n\n" + name
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(name, CallbackData("/attachment_send", attachment.ID))))
+	}
+
+	if row := pageRow(current, pages, func(page int) string {
+		return CallbackData("/card_attachments", taskID, page)
+	}); row != nil {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_attach"), CallbackData("/card_attach", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "back"), CallbackData("/card", taskID))))
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// AttachmentFile returns the message re-sending a stored attachment to chatID.
+func AttachmentFile(chatID int64, attachment *model.Attachment) tgbotapi.Chattable {
+	file := tgbotapi.FileID(attachment.FileID)
+	switch attachment.Kind {
+	case model.AttachmentPhoto:
+		return tgbotapi.NewPhoto(chatID, file)
+	case model.AttachmentVoice:
+		return tgbotapi.NewVoice(chatID, file)
+	default:
+		return tgbotapi.NewDocument(chatID, file)
+	}
+}
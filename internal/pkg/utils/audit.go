@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+const (
+	// HistoryPageSize is the number of audit entries on a page of /history.
+	HistoryPageSize = 10
+
+	auditValueLimit   = 40
+	auditChangesLimit = 5
+)
+
+// HistoryPage renders the audit entries of a team, or of a task when taskID
+// is set, with a button to older entries when more is true.
+func HistoryPage(texts map[string]string, loc *time.Location, taskID int, entries []*model.AuditEntry, more bool) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := GetFormatText(texts, "history_team")
+	if taskID != 0 {
+		text = GetFormatText(texts, "history_task", taskID)
+	}
+
+	for _, entry := range entries {
+		text += "\n\n" + AuditLine(texts, loc, entry)
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if more {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "history_older"), CallbackData("/history", taskID, entries[len(entries)-1].ID)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "history_latest"), CallbackData("/history", taskID, 0)))
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// AuditLine describes an audit entry: when, who, what and the changed fields.
+func AuditLine(texts map[string]string, loc *time.Location, entry *model.AuditEntry) string {
+	actor := GetFormatText(texts, "audit_bot")
+	if entry.ActorID != 0 {
+		actor = entry.ActorLogin
+		if actor == "" {
+			actor = fmt.Sprint(entry.ActorID)
+		}
+	}
+
+	target := GetFormatText(texts, "audit_target_"+entry.Target)
+	if entry.Target == "task" {
+		target += " #" + entry.TargetID
+	} else if entry.TaskID != 0 {
+		target += GetFormatText(texts, "audit_of_task", entry.TaskID)
+	}
+
+	line := GetFormatText(texts, "audit_line", entry.CreatedAt.In(loc).Format("02.01 15:04"), actor, GetFormatText(texts, "audit_"+entry.Action), target)
+	if entry.Action == model.AuditUpdate {
+		changes := auditChanges(entry.Before, entry.After)
+		for i, change := range changes {
+			if i == auditChangesLimit {
+				line += "\n  …"
+				break
+			}
+
+			line += "\n  " + change
+		}
+	}
+
+	return line
+}
+
+// auditChanges lists the fields that differ between the row before and after
+// an update as "field: before → after".
+func auditChanges(before, after json.RawMessage) []string {
+	var b, a map[string]any
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil {
+		return nil
+	}
+
+	var changes []string
+	for key, value := range a {
+		if fmt.Sprint(b[key]) == fmt.Sprint(value) {
+			continue
+		}
+
+		changes = append(changes, key+": "+auditValue(b[key])+" → "+auditValue(value))
+	}
+	slices.Sort(changes)
+
+	return changes
+}
+
+func auditValue(v any) string {
+	if v == nil {
+		return "—"
+	}
+
+	s := strings.ReplaceAll(fmt.Sprint(v), "\n", " ")
+	if r := []rune(s); len(r) > auditValueLimit {
+		s = string(r[:auditValueLimit]) + "…"
+	}
+
+	return s
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const callbackSeparator = ":"
+
+// CallbackData joins a callback command and its arguments, e.g. "/card_done:12".
+func CallbackData(command string, args ...any) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, command)
+	for _, arg := range args {
+		parts = append(parts, fmt.Sprint(arg))
+	}
+
+	return strings.Join(parts, callbackSeparator)
+}
+
+// ParseCallbackData splits data built by CallbackData into the command and its arguments.
+func ParseCallbackData(data string) (string, []string) {
+	parts := strings.Split(data, callbackSeparator)
+	return parts[0], parts[1:]
+}
+
+// IntArg returns the i-th argument as int.
+func IntArg(args []string, i int) (int, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("argument %d not found", i)
+	}
+
+	return strconv.Atoi(args[i])
+}
+
+// Int64Arg returns the i-th argument as int64.
+func Int64Arg(args []string, i int) (int64, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("argument %d not found", i)
+	}
+
+	return strconv.ParseInt(args[i], 10, 64)
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// TaskCard renders a task as a standalone message with its inline actions.
+// The deadline is shown in loc, the time zone of the viewer.
+func TaskCard(texts map[string]string, loc *time.Location, task *model.Tasks) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := GetFormatText(texts, "task_card", task.ID, GetFormatText(texts, "status_"+task.Status), task.Complexity, FormatDeadline(texts, task.Deadline, loc), task.Description)
+	text += "\n\n" + GetFormatText(texts, "task_priority", PriorityName(texts, task.Priority))
+
+	if len(task.Labels) > 0 {
+		text += "\n\n" + GetFormatText(texts, "task_labels", strings.Join(task.Labels, ", "))
+	}
+
+	if len(task.BlockedBy) > 0 {
+		text += "\n\n" + GetFormatText(texts, "task_blocked_by", TaskIDs(task.BlockedBy))
+	}
+
+	if progress := TaskProgress(texts, task); progress != "" {
+		text += "\n\n" + progress
+	}
+
+	if task.Attachments > 0 {
+		text += "\n\n" + GetFormatText(texts, "task_attachments", task.Attachments)
+	}
+
+	comments := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_checklist"), CallbackData("/card_checklist", task.ID)),
+		tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_comments"), CallbackData("/card_comments", task.ID)),
+		tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_attachments"), CallbackData("/card_attachments", task.ID)))
+
+	var series []tgbotapi.InlineKeyboardButton
+	if task.SeriesID != 0 {
+		text += "\n\n" + GetFormatText(texts, "task_recurring")
+		series = tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_series"), CallbackData("/card_series", task.ID)))
+	}
+
+	if task.UserID == 0 {
+		text += "\n\n" + GetFormatText(texts, "task_unassigned")
+	}
+
+	if task.Status == model.TaskDone {
+		markUp := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_delete"), CallbackData("/card_delete", task.ID))),
+			comments)
+		if series != nil {
+			markUp.InlineKeyboard = append(markUp.InlineKeyboard, series)
+		}
+
+		return text, markUp
+	}
+
+	markUp := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_done"), CallbackData("/card_done", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_edit"), CallbackData("/card_edit", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_delete"), CallbackData("/card_delete", task.ID))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_reassign"), CallbackData("/card_reassign", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_postpone"), CallbackData("/card_postpone", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_labels"), CallbackData("/card_labels", task.ID))),
+		comments)
+	if task.UserID == 0 {
+		markUp.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_claim"), CallbackData("/card_claim", task.ID)))},
+			markUp.InlineKeyboard...)
+	}
+	if series != nil {
+		markUp.InlineKeyboard = append(markUp.InlineKeyboard, series)
+	}
+
+	return text, markUp
+}
+
+// BackToCard returns a keyboard with a single button restoring the task card.
+func BackToCard(texts map[string]string, taskID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "back"), CallbackData("/card", taskID))))
+}
+
+// UndoDelete returns a keyboard restoring a deleted task.
+func UndoDelete(texts map[string]string, taskID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "undo"), CallbackData("/task_undo", taskID))))
+}
+
+// EditFields returns a keyboard to choose which field of the task to edit.
+func EditFields(texts map[string]string, taskID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "field_"+model.FieldComplexity), CallbackData("/card_edit_field", taskID, model.FieldComplexity)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "field_"+model.FieldDeadline), CallbackData("/card_edit_field", taskID, model.FieldDeadline)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "field_"+model.FieldDescription), CallbackData("/card_edit_field", taskID, model.FieldDescription)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "field_"+model.FieldPriority), CallbackData("/card_edit_field", taskID, model.FieldPriority))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "back"), CallbackData("/card", taskID))))
+}
+
+// CardTaskID returns the ID of the task whose card msg is, found in the
+// callback data of its inline buttons, e.g. for a reply to the card. Messages
+// with buttons of several tasks, like task lists, are not cards.
+func CardTaskID(msg *tgbotapi.Message) (int, bool) {
+	if msg == nil || msg.ReplyMarkup == nil {
+		return 0, false
+	}
+
+	taskID := 0
+	for _, row := range msg.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == nil {
+				continue
+			}
+
+			command, args := ParseCallbackData(*button.CallbackData)
+			if !strings.HasPrefix(command, "/card") {
+				continue
+			}
+
+			id, err := IntArg(args, 0)
+			if err != nil {
+				continue
+			}
+
+			if taskID != 0 && id != taskID {
+				return 0, false
+			}
+
+			taskID = id
+		}
+	}
+
+	return taskID, taskID != 0
+}
+
+// TaskIDs lists task IDs separated by commas.
+func TaskIDs(ids []int) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// ChecklistPageSize is the number of checklist items shown at once.
+const ChecklistPageSize = 10
+
+// ParseChecklist returns the checklist items sent one per line, skipping empty lines.
+func ParseChecklist(text string) []string {
+	var items []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+
+	return items
+}
+
+// TaskProgress returns the checklist progress of a task, e.g. "3/7", or an
+// empty string when the task has no checklist.
+func TaskProgress(texts map[string]string, task *model.Tasks) string {
+	if task.Items == 0 {
+		return ""
+	}
+
+	return GetFormatText(texts, "task_progress", task.ItemsDone, task.Items)
+}
+
+// ChecklistPage renders one page of the checklist of a task with a toggle
+// button for each item. It is paged with "/card_checklist:<task>:<page>".
+func ChecklistPage(texts map[string]string, taskID int, items []*model.TaskItem, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	from, to, current, pages := PageBounds(len(items), ChecklistPageSize, page)
+
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+
+	text := GetFormatText(texts, "checklist_title", taskID, done, len(items))
+	if len(items) == 0 {
+		text += "\n\n" + GetFormatText(texts, "checklist_empty")
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, item := range items[from:to] {
+		mark := "☐ "
+		if item.Done {
+			mark = "☑ "
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark+item.Text, CallbackData("/card_item", taskID, item.ID, current))))
+	}
+
+	if row := pageRow(current, pages, func(page int) string {
+		return CallbackData("/card_checklist", taskID, page)
+	}); row != nil {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "checklist_add"), CallbackData("/card_checklist_add", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "back"), CallbackData("/card", taskID))))
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
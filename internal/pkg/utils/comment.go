@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// CommentsPage renders one page of the comment thread of a task. The thread is
+// paged with "/card_comments:<task>:<page>" since it belongs to a single task.
+func CommentsPage(texts map[string]string, loc *time.Location, taskID int, comments []*model.Comment, watching bool, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	from, to, current, pages := PageBounds(len(comments), size, page)
+
+	text := GetFormatText(texts, "comments_title", taskID, len(comments))
+	if len(comments) == 0 {
+		text += "\n\n" + GetFormatText(texts, "no_comments")
+	} else {
+		text += "\n" + GetFormatText(texts, "page_of", current+1, pages)
+	}
+
+	for i, comment := range comments[from:to] {
+		text += "\n\n" + strconv.Itoa(from+i+1) + ". " + GetFormatText(texts, "comment_line", comment.Login, FormatDeadline(texts, comment.CreatedAt, loc), comment.Text)
+	}
+
+	watch := "card_watch"
+	if watching {
+		watch = "card_unwatch"
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if row := pageRow(current, pages, func(page int) string {
+		return CallbackData("/card_comments", taskID, page)
+	}); row != nil {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_comment"), CallbackData("/card_comment", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, watch), CallbackData("/card_watch", taskID))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "back"), CallbackData("/card", taskID))))
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CommentNotice returns the buttons sent with a new comment: open the task and reply.
+func CommentNotice(texts map[string]string, taskID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "open_task", taskID), CallbackData("/card_open", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_comment"), CallbackData("/card_comment", taskID))))
+}
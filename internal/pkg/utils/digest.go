@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// DigestItem is a task of the digest sent as its own message with quick actions.
+type DigestItem struct {
+	Text   string
+	MarkUp tgbotapi.InlineKeyboardMarkup
+}
+
+// DigestMessages renders the digest as a summary followed by a message with
+// quick actions for each task, at most limit of them.
+func DigestMessages(texts map[string]string, loc *time.Location, digest *model.Digest, limit int) (string, []DigestItem) {
+	summary := GetFormatText(texts, "digest_summary", len(digest.Overdue), len(digest.Today), len(digest.Assigned))
+
+	sections := []struct {
+		key   string
+		tasks []*model.Tasks
+	}{
+		{"digest_overdue", digest.Overdue},
+		{"digest_today", digest.Today},
+		{"digest_assigned", digest.Assigned},
+	}
+
+	var items []DigestItem
+	total := 0
+	for _, section := range sections {
+		for _, task := range section.tasks {
+			total++
+			if len(items) == limit {
+				continue
+			}
+
+			text := GetFormatText(texts, section.key) + "\n" + PriorityMarker(task.Priority) + " " +
+				GetFormatText(texts, "task_line", task.ID, GetFormatText(texts, "status_"+task.Status), task.Complexity, FormatDeadline(texts, task.Deadline, loc), task.Description)
+			items = append(items, DigestItem{Text: text, MarkUp: digestActions(texts, task.ID)})
+		}
+	}
+
+	if total > len(items) {
+		summary += "\n\n" + GetFormatText(texts, "digest_more", total-len(items))
+	}
+
+	return summary, items
+}
+
+func digestActions(texts map[string]string, taskID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_done"), CallbackData("/card_done", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "card_postpone"), CallbackData("/card_postpone", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "open_task", taskID), CallbackData("/card", taskID))))
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+var exportHeader = []string{"id", "status", "priority", "complexity", "deadline", "description",
+	"assignee_id", "creator_id", "team_id", "labels", "items", "items_done", "blocked_by"}
+
+// exportTask is a task as written to a JSON export.
+type exportTask struct {
+	ID          int      `json:"id"`
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority"`
+	Complexity  int      `json:"complexity"`
+	Deadline    string   `json:"deadline"`
+	Description string   `json:"description"`
+	AssigneeID  int64    `json:"assignee_id,omitempty"`
+	CreatorID   int64    `json:"creator_id,omitempty"`
+	TeamID      int      `json:"team_id,omitempty"`
+	Labels      []string `json:"labels"`
+	Items       int      `json:"items"`
+	ItemsDone   int      `json:"items_done"`
+	BlockedBy   []int    `json:"blocked_by"`
+}
+
+// ExportFilter returns the filter of an export scope. Exports include done
+// tasks.
+func ExportFilter(scope string, userID int64, teamID int) (*model.TaskFilter, error) {
+	f := model.DefaultTaskFilter(userID)
+	f.Status = model.FilterAll
+
+	switch scope {
+	case model.ExportMine:
+	case model.ExportIssued:
+		f.AssigneeID, f.CreatorID = 0, userID
+	case model.ExportTeam:
+		f.AssigneeID, f.TeamID = 0, teamID
+	default:
+		return nil, fmt.Errorf("invalid export scope %q", scope)
+	}
+
+	return f, nil
+}
+
+// ExportKeyboard returns the keyboard choosing the scope and format of an export.
+func ExportKeyboard(texts map[string]string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, scope := range model.ExportScopes {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, format := range model.ExportFormats {
+			label := GetFormatText(texts, "export_"+scope, strings.ToUpper(format))
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, CallbackData("/export", scope, format)))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// ExportDocument renders the tasks to a file in the format and returns the
+// message sending it to chatID. Deadlines are written in loc.
+func ExportDocument(chatID int64, format string, loc *time.Location, tasks []*model.Tasks) (tgbotapi.DocumentConfig, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case model.ExportCSV:
+		data, err = exportCSV(loc, tasks)
+	case model.ExportJSON:
+		data, err = exportJSON(loc, tasks)
+	default:
+		err = fmt.Errorf("invalid export format %q", format)
+	}
+	if err != nil {
+		return tgbotapi.DocumentConfig{}, err
+	}
+
+	name := "tasks-" + time.Now().In(loc).Format(time.DateOnly) + "." + format
+
+	return tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: data}), nil
+}
+
+// spreadsheetSafe keeps spreadsheets from running a cell typed by a user as
+// a formula by quoting the characters a formula starts with.
+func spreadsheetSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+func exportCSV(loc *time.Location, tasks []*model.Tasks) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err := w.Write(exportHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		err = w.Write([]string{
+			strconv.Itoa(task.ID),
+			task.Status,
+			task.Priority,
+			strconv.Itoa(task.Complexity),
+			exportDeadline(loc, task.Deadline),
+			spreadsheetSafe(task.Description),
+			exportID(task.UserID),
+			exportID(task.CreatorID),
+			exportID(int64(task.TeamID)),
+			spreadsheetSafe(strings.Join(task.Labels, ";")),
+			strconv.Itoa(task.Items),
+			strconv.Itoa(task.ItemsDone),
+			strings.ReplaceAll(TaskIDs(task.BlockedBy), ", ", ";"),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func exportJSON(loc *time.Location, tasks []*model.Tasks) ([]byte, error) {
+	out := make([]exportTask, 0, len(tasks))
+	for _, task := range tasks {
+		out = append(out, exportTask{
+			ID:          task.ID,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			Complexity:  task.Complexity,
+			Deadline:    exportDeadline(loc, task.Deadline),
+			Description: task.Description,
+			AssigneeID:  task.UserID,
+			CreatorID:   task.CreatorID,
+			TeamID:      task.TeamID,
+			Labels:      append([]string{}, task.Labels...),
+			Items:       task.Items,
+			ItemsDone:   task.ItemsDone,
+			BlockedBy:   append([]int{}, task.BlockedBy...),
+		})
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func exportDeadline(loc *time.Location, deadline time.Time) string {
+	if deadline.IsZero() {
+		return ""
+	}
+
+	return deadline.In(loc).Format(time.RFC3339)
+}
+
+func exportID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+const (
+	FilterStatus     = "status"
+	FilterDue        = "due"
+	FilterComplexity = "complexity"
+	FilterAssignee   = "assignee"
+	FilterCreator    = "creator"
+	FilterTeam       = "team"
+	FilterLabel      = "label"
+	FilterSort       = "sort"
+	FilterFrom       = "from"
+	FilterTo         = "to"
+	// FilterScope is a toggle setting assignee and creator at once.
+	FilterScope = "scope"
+
+	filterMe = "me"
+)
+
+var filterToggles = []struct {
+	key    string
+	values []string
+}{
+	{FilterStatus, []string{model.TaskOpen, model.TaskDone, model.FilterAll}},
+	{FilterDue, []string{model.DueOverdue, model.DueToday, model.DueWeek, model.FilterAll}},
+	{FilterSort, []string{model.SortPressing, model.SortDeadline, model.SortComplexity, model.SortCreated}},
+	{FilterScope, []string{FilterAssignee, FilterCreator, model.FilterAll}},
+}
+
+// ParseTaskFilter builds a filter from "/tasks" arguments like
+// "status=open due=week complexity=3-7 assignee=me label=bug sort=created"
+// or "from=2026-10-01 to=2026-10-31".
+func ParseTaskFilter(args []string, userID int64) (*model.TaskFilter, error) {
+	f := model.DefaultTaskFilter(userID)
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid filter %q", arg)
+		}
+
+		err := ApplyTaskFilter(f, key, value, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// ApplyTaskFilter sets one filter key. "all" clears the key.
+func ApplyTaskFilter(f *model.TaskFilter, key, value string, userID int64) error {
+	var err error
+	switch key {
+	case FilterStatus:
+		if value != model.TaskOpen && value != model.TaskDone && value != model.FilterAll {
+			return fmt.Errorf("invalid status %q", value)
+		}
+		f.Status = value
+	case FilterDue:
+		if value != model.DueOverdue && value != model.DueToday && value != model.DueWeek && value != model.FilterAll {
+			return fmt.Errorf("invalid due %q", value)
+		}
+		f.Due = value
+	case FilterComplexity:
+		f.MinComplexity, f.MaxComplexity, err = parseComplexityRange(value)
+	case FilterAssignee:
+		f.AssigneeID, err = parseFilterUser(value, userID)
+	case FilterCreator:
+		f.CreatorID, err = parseFilterUser(value, userID)
+	case FilterScope:
+		f.AssigneeID, f.CreatorID = 0, 0
+		switch value {
+		case FilterAssignee:
+			f.AssigneeID = userID
+		case FilterCreator:
+			f.CreatorID = userID
+		case model.FilterAll:
+		default:
+			return fmt.Errorf("invalid scope %q", value)
+		}
+	case FilterTeam:
+		f.TeamID = 0
+		if value != model.FilterAll {
+			f.TeamID, err = strconv.Atoi(value)
+		}
+	case FilterLabel:
+		f.Label = ""
+		if value != model.FilterAll {
+			f.Label, err = NormalizeLabel(value)
+		}
+	case FilterFrom:
+		f.From, err = parseFilterDate(value)
+	case FilterTo:
+		f.To, err = parseFilterDate(value)
+	case FilterSort:
+		if value != model.SortDeadline && value != model.SortComplexity && value != model.SortCreated && value != model.SortPressing {
+			return fmt.Errorf("invalid sort %q", value)
+		}
+		f.Sort = value
+	default:
+		return fmt.Errorf("unknown filter %q", key)
+	}
+
+	return err
+}
+
+func parseComplexityRange(value string) (int, int, error) {
+	if value == model.FilterAll {
+		return 0, 0, nil
+	}
+
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		to = from
+	}
+
+	minC, err := ParseComplexity(from)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	maxC, err := ParseComplexity(to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if minC > maxC {
+		return 0, 0, fmt.Errorf("invalid complexity range %q", value)
+	}
+
+	return minC, maxC, nil
+}
+
+func parseFilterDate(value string) (string, error) {
+	if value == model.FilterAll {
+		return "", nil
+	}
+
+	_, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q", value)
+	}
+
+	return value, nil
+}
+
+func parseFilterUser(value string, userID int64) (int64, error) {
+	switch value {
+	case filterMe:
+		return userID, nil
+	case model.FilterAll:
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func filterValue(f *model.TaskFilter, key string, userID int64) string {
+	switch key {
+	case FilterStatus:
+		return f.Status
+	case FilterDue:
+		return f.Due
+	case FilterSort:
+		return f.Sort
+	case FilterScope:
+		switch {
+		case f.AssigneeID == userID && f.CreatorID == 0:
+			return FilterAssignee
+		case f.CreatorID == userID && f.AssigneeID == 0:
+			return FilterCreator
+		case f.AssigneeID == 0 && f.CreatorID == 0:
+			return model.FilterAll
+		}
+	}
+
+	return ""
+}
+
+// FilterRows returns the inline toggles of the task filter with the active values marked.
+func FilterRows(texts map[string]string, f *model.TaskFilter, userID int64) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, toggle := range filterToggles {
+		active := filterValue(f, toggle.key, userID)
+		if active == "" && toggle.key == FilterDue {
+			active = model.FilterAll
+		}
+
+		var row []tgbotapi.InlineKeyboardButton
+		for _, value := range toggle.values {
+			label := GetFormatText(texts, "filter_"+toggle.key+"_"+value)
+			if value == active {
+				label = "✓ " + label
+			}
+
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, CallbackData("/filter", toggle.key, value)))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// FilterSummary describes the filter settings that cannot be seen on the toggles.
+func FilterSummary(texts map[string]string, f *model.TaskFilter, userID int64) string {
+	var parts []string
+	if f.MinComplexity > 0 || f.MaxComplexity > 0 {
+		parts = append(parts, GetFormatText(texts, "filter_complexity_range", f.MinComplexity, f.MaxComplexity))
+	}
+	if filterValue(f, FilterScope, userID) == "" {
+		if f.AssigneeID != 0 {
+			parts = append(parts, GetFormatText(texts, "filter_assignee_id", f.AssigneeID))
+		}
+		if f.CreatorID != 0 {
+			parts = append(parts, GetFormatText(texts, "filter_creator_id", f.CreatorID))
+		}
+	}
+	if f.TeamID != 0 {
+		parts = append(parts, GetFormatText(texts, "filter_team_id", f.TeamID))
+	}
+	if f.Label != "" {
+		parts = append(parts, GetFormatText(texts, "filter_label_name", f.Label))
+	}
+	if f.From != "" || f.To != "" {
+		from, to := f.From, f.To
+		if from == "" {
+			from = "…"
+		}
+		if to == "" {
+			to = "…"
+		}
+		parts = append(parts, GetFormatText(texts, "filter_dates", from, to))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// FilteredTasksPage renders a page of filtered tasks followed by the filter toggles.
+func FilteredTasksPage(texts map[string]string, loc *time.Location, f *model.TaskFilter, userID int64, tasks []*model.Tasks, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	title := GetFormatText(texts, "tasks_filtered", len(tasks))
+	if summary := FilterSummary(texts, f, userID); summary != "" {
+		title += "\n" + summary
+	}
+
+	text, markUp := TasksPage(texts, loc, ListFilteredTasks, title, tasks, page, size)
+	markUp.InlineKeyboard = append(markUp.InlineKeyboard, FilterRows(texts, f, userID)...)
+
+	return text, markUp
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+	"tgbot/internal/pkg/deadline"
+)
+
+const (
+	// ImportMaxSize is the largest CSV file accepted for import, in bytes.
+	ImportMaxSize = 1 << 20
+	// ImportMaxRows is the largest number of rows imported at once.
+	ImportMaxRows = 500
+
+	importPreviewRows = 20
+)
+
+var ErrImportTooLarge = errors.New("too many rows to import")
+
+// ParseImport validates the rows "assignee,complexity,deadline,description" of
+// a CSV file. The assignee is a login or user ID of a team member, deadlines
+// are read in the location of now. A first row naming the columns is skipped.
+func ParseImport(data []byte, members []*model.User, now time.Time) ([]*model.ImportRow, []*model.ImportError, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var (
+		rows []*model.ImportRow
+		errs []*model.ImportError
+	)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, &model.ImportError{Line: parseErr.StartLine, Reason: "import_err_format"})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "assignee") {
+			continue
+		}
+
+		if len(rows)+len(errs) == ImportMaxRows {
+			return nil, nil, ErrImportTooLarge
+		}
+
+		row, importErr := parseImportRow(line, record, members, now)
+		if importErr != nil {
+			errs = append(errs, importErr)
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, errs, nil
+}
+
+func parseImportRow(line int, record []string, members []*model.User, now time.Time) (*model.ImportRow, *model.ImportError) {
+	if len(record) != 4 {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_columns", Value: strconv.Itoa(len(record))}
+	}
+
+	for i, column := range []string{"complexity", "deadline"} {
+		if strings.TrimSpace(record[i+1]) == "" {
+			return nil, &model.ImportError{Line: line, Reason: "import_err_empty", Value: column}
+		}
+	}
+
+	row := &model.ImportRow{Line: line}
+	assignee := strings.TrimPrefix(strings.TrimSpace(record[0]), "@")
+	for _, member := range members {
+		if member.Login == assignee || strconv.FormatInt(member.ID, 10) == assignee {
+			row.AssigneeID, row.Assignee = member.ID, member.Login
+			break
+		}
+	}
+	if assignee == "" {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_no_assignee"}
+	}
+	if row.AssigneeID == 0 {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_assignee", Value: assignee}
+	}
+
+	var err error
+	row.Complexity, err = ParseComplexity(record[1])
+	if err != nil {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_complexity", Value: record[1]}
+	}
+
+	row.Deadline, err = deadline.Parse(record[2], now)
+	if errors.Is(err, deadline.ErrPast) {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_deadline_past", Value: record[2]}
+	}
+	if err != nil {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_deadline", Value: record[2]}
+	}
+
+	row.Description, err = ParseDescription(record[3])
+	if err != nil {
+		return nil, &model.ImportError{Line: line, Reason: "import_err_description"}
+	}
+
+	return row, nil
+}
+
+// ImportPreview describes the rows to be imported and the rows with errors and
+// asks to confirm the import when there is anything to import.
+func ImportPreview(texts map[string]string, loc *time.Location, rows []*model.ImportRow, errs []*model.ImportError) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := GetFormatText(texts, "import_preview", len(rows), len(errs))
+
+	for i, row := range rows {
+		if i == importPreviewRows {
+			text += "\n" + GetFormatText(texts, "import_more", len(rows)-i)
+			break
+		}
+
+		text += "\n" + GetFormatText(texts, "import_row", row.Line, row.Assignee, row.Complexity, FormatDeadline(texts, row.Deadline, loc), row.Description)
+	}
+
+	if len(errs) > 0 {
+		text += "\n\n" + GetFormatText(texts, "import_errors")
+	}
+	for i, e := range errs {
+		if i == importPreviewRows {
+			text += "\n" + GetFormatText(texts, "import_more", len(errs)-i)
+			break
+		}
+
+		reason := GetFormatText(texts, e.Reason)
+		if e.Value != "" {
+			reason = GetFormatText(texts, e.Reason, e.Value)
+		}
+
+		text += "\n" + GetFormatText(texts, "import_error_line", e.Line, reason)
+	}
+
+	cancel := tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "import_cancel"), CallbackData("/import_cancel"))
+	if len(rows) == 0 {
+		return text, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(cancel))
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "import_confirm", len(rows)), CallbackData("/import_confirm")),
+		cancel))
+}
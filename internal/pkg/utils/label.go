@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+const maxLabelLength = 32
+
+var ErrInvalidLabel = errors.New("label must be one word of at most 32 characters")
+
+// NormalizeLabel lowercases a label name and drops a leading "#".
+func NormalizeLabel(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if name == "" || utf8.RuneCountInString(name) > maxLabelLength || strings.IndexFunc(name, unicode.IsSpace) >= 0 || strings.Contains(name, callbackSeparator) {
+		return "", ErrInvalidLabel
+	}
+
+	return name, nil
+}
+
+// ParseLabels normalizes label names separated by spaces or commas.
+func ParseLabels(args []string) ([]string, error) {
+	var labels []string
+	for _, arg := range args {
+		for _, name := range strings.Split(arg, ",") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+
+			label, err := NormalizeLabel(name)
+			if err != nil {
+				return nil, err
+			}
+
+			labels = append(labels, label)
+		}
+	}
+
+	if labels == nil {
+		return nil, ErrInvalidLabel
+	}
+
+	return labels, nil
+}
+
+// SplitSearchQuery separates "#label" tokens of a search query from the words
+// searched in descriptions.
+func SplitSearchQuery(query string) (string, []string) {
+	var words, labels []string
+	for _, token := range strings.Fields(query) {
+		if strings.HasPrefix(token, "#") {
+			if label, err := NormalizeLabel(token); err == nil {
+				labels = append(labels, label)
+				continue
+			}
+		}
+
+		words = append(words, token)
+	}
+
+	return strings.Join(words, " "), labels
+}
+
+// LabelNames lists label names separated by commas.
+func LabelNames(labels []*model.Label) string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// LabelToggles returns the team labels as toggles of the task with the labels
+// it carries marked, followed by a button back to the card.
+func LabelToggles(texts map[string]string, task *model.Tasks, labels []*model.Label) tgbotapi.InlineKeyboardMarkup {
+	const perRow = 3
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, label := range labels {
+		name := label.Name
+		if slices.Contains(task.Labels, label.Name) {
+			name = "✓ " + name
+		}
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(name, CallbackData("/card_label", task.ID, label.ID)))
+		if len(row) == perRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if row != nil {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "labels_done"), CallbackData("/card", task.ID))))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+// Lists that can be paged with the "/page:<list>:<page>" callback.
+const (
+	ListTasks         = "tasks"
+	ListFilteredTasks = "filtered"
+	ListSearch        = "search"
+	ListBacklog       = "backlog"
+	ListTeam          = "team"
+	ListDeleteUser    = "delete_user"
+	ListCreateTask    = "create_task"
+)
+
+const pageButtons = 5
+
+// A page of tasks has to fit in one Telegram message of messageLimit
+// characters. Each line keeps lineOverhead of its share for the number,
+// status, deadline, progress and labels around the description.
+const (
+	messageLimit       = 4096
+	lineOverhead       = 250
+	minDescriptionSize = 40
+)
+
+var teamListTexts = map[string]string{
+	ListTeam:       "team_info",
+	ListDeleteUser: "delete_user_text",
+	ListCreateTask: "choose_user_to_add_task",
+}
+
+// PageBounds clamps page to the available pages and returns the slice bounds
+// of its items together with the clamped page and the number of pages.
+func PageBounds(total, size, page int) (from, to, current, pages int) {
+	if size <= 0 {
+		size = total
+	}
+
+	pages = 1
+	if total > 0 && size > 0 {
+		pages = (total + size - 1) / size
+	}
+
+	current = min(max(page, 0), pages-1)
+	from = min(current*size, total)
+	to = min(from+size, total)
+
+	return from, to, current, pages
+}
+
+// PageRow returns the navigation buttons of a list: previous, a window of
+// page numbers with the current one marked, and next. It is nil for a single page.
+func PageRow(list string, current, pages int) []tgbotapi.InlineKeyboardButton {
+	return pageRow(current, pages, func(page int) string {
+		return CallbackData("/page", list, page)
+	})
+}
+
+// pageRow builds the navigation buttons with data returning the callback data
+// of a page, for lists paged by their own callback.
+func pageRow(current, pages int, data func(page int) string) []tgbotapi.InlineKeyboardButton {
+	if pages <= 1 {
+		return nil
+	}
+
+	first := max(0, min(current-pageButtons/2, pages-pageButtons))
+	last := min(pages, first+pageButtons)
+
+	var row []tgbotapi.InlineKeyboardButton
+	if current > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("«", data(current-1)))
+	}
+
+	for p := first; p < last; p++ {
+		label := strconv.Itoa(p + 1)
+		if p == current {
+			label = "·" + label + "·"
+		}
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data(p)))
+	}
+
+	if current < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("»", data(current+1)))
+	}
+
+	return row
+}
+
+// TasksPage renders one page of tasks under title with a button opening the
+// card of each task.
+func TasksPage(texts map[string]string, loc *time.Location, list, title string, tasks []*model.Tasks, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	from, to, current, pages := PageBounds(len(tasks), size, page)
+
+	text := title + "\n" + GetFormatText(texts, "page_of", current+1, pages)
+	budget := max(messageLimit/max(size, 1)-lineOverhead, minDescriptionSize)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, task := range tasks[from:to] {
+		text += "\n\n" + strconv.Itoa(from+i+1) + ". " + PriorityMarker(task.Priority) + " " + GetFormatText(texts, "task_line", task.ID, GetFormatText(texts, "status_"+task.Status), task.Complexity, FormatDeadline(texts, task.Deadline, loc), truncate(task.Description, budget))
+		if progress := TaskProgress(texts, task); progress != "" {
+			text += "\n" + progress
+		}
+		if len(task.Labels) > 0 {
+			text += "\n#" + strings.Join(task.Labels, " #")
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "open_task", task.ID), CallbackData("/card_open", task.ID))))
+	}
+
+	if row := PageRow(list, current, pages); row != nil {
+		rows = append(rows, row)
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// truncate cuts s to at most n runes, marking the cut with "…".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-1]) + "…"
+}
+
+// SearchPage renders one page of search results with matches highlighted in
+// place of the descriptions.
+func SearchPage(texts map[string]string, loc *time.Location, query string, results []*model.SearchResult, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	tasks := make([]*model.Tasks, 0, len(results))
+	for _, result := range results {
+		task := *result.Task
+		task.Description = result.Headline
+		tasks = append(tasks, &task)
+	}
+
+	return TasksPage(texts, loc, ListSearch, GetFormatText(texts, "search_results", query, len(results)), tasks, page, size)
+}
+
+// TeamPage renders one page of team members for the given team list.
+func TeamPage(texts map[string]string, list string, team *model.Team, page, size int) (string, tgbotapi.InlineKeyboardMarkup) {
+	from, to, current, pages := PageBounds(len(team.Users), size, page)
+
+	var users string
+	for i, user := range team.Users[from:to] {
+		users += strconv.Itoa(from+i+1) + ". " + user.Login + "\n"
+		if list != ListTeam {
+			users += strconv.FormatInt(user.ID, 10) + "\n"
+		}
+	}
+
+	text := GetFormatText(texts, teamListTexts[list], users)
+	if list == ListTeam {
+		text = GetFormatText(texts, teamListTexts[list], team.Name, users)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if row := PageRow(list, current, pages); row != nil {
+		rows = append(rows, row)
+	}
+
+	if list == ListCreateTask {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(GetFormatText(texts, "create_backlog_task"), CallbackData("/create_backlog"))))
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
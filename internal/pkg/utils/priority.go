@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"tgbot/internal/model"
+)
+
+var priorityMarkers = map[string]string{
+	model.PriorityLow:    "⚪",
+	model.PriorityNormal: "🔵",
+	model.PriorityHigh:   "🟠",
+	model.PriorityUrgent: "🔴",
+}
+
+// ValidPriority reports whether priority is one of model.Priorities.
+func ValidPriority(priority string) bool {
+	return slices.Contains(model.Priorities, priority)
+}
+
+// PriorityMarker returns the colored marker of a priority shown on cards and lists.
+func PriorityMarker(priority string) string {
+	if marker, ok := priorityMarkers[priority]; ok {
+		return marker
+	}
+
+	return priorityMarkers[model.PriorityNormal]
+}
+
+// PriorityName returns the marker and the localized name of a priority.
+func PriorityName(texts map[string]string, priority string) string {
+	if !ValidPriority(priority) {
+		priority = model.PriorityNormal
+	}
+
+	return PriorityMarker(priority) + " " + GetFormatText(texts, "priority_"+priority)
+}
+
+// PriorityRow returns a button for each priority with data returning its callback data.
+func PriorityRow(texts map[string]string, data func(priority string) string) []tgbotapi.InlineKeyboardButton {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(model.Priorities))
+	for _, priority := range model.Priorities {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(PriorityName(texts, priority), data(priority)))
+	}
+
+	return row
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/internal/pkg/recurrence"
+)
+
+var workWeek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// DescribeRule prints a recurrence rule in words, e.g. "каждую неделю: пн, пт".
+func DescribeRule(texts map[string]string, rule *recurrence.Rule) string {
+	var text string
+	switch rule.Freq {
+	case recurrence.Daily:
+		text = GetFormatText(texts, "rule_daily")
+		if rule.Interval > 1 {
+			text = GetFormatText(texts, "rule_every_days", rule.Interval)
+		}
+	case recurrence.Weekly:
+		if rule.Interval == 1 && slices.Equal(rule.Weekdays, workWeek) {
+			text = GetFormatText(texts, "rule_weekdays")
+			break
+		}
+
+		days := make([]string, 0, len(rule.Weekdays))
+		for _, day := range rule.Weekdays {
+			days = append(days, GetFormatText(texts, "weekday_"+strconv.Itoa(int(day))))
+		}
+		text = GetFormatText(texts, "rule_weekly", strings.Join(days, ", "))
+		if rule.Interval > 1 {
+			text = GetFormatText(texts, "rule_every_weeks", rule.Interval, strings.Join(days, ", "))
+		}
+	case recurrence.Monthly:
+		text = GetFormatText(texts, "rule_monthly", rule.MonthDay)
+		if rule.Interval > 1 {
+			text = GetFormatText(texts, "rule_every_months", rule.Interval, rule.MonthDay)
+		}
+	}
+
+	if !rule.Until.IsZero() {
+		text += GetFormatText(texts, "rule_until", rule.Until.Format("02.01.2006"))
+	}
+
+	return text
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+// FormatReport renders the weekly workload report of a team with the
+// week-over-week change of every figure.
+func FormatReport(texts map[string]string, teamName string, reports []*model.MemberReport) string {
+	text := GetFormatText(texts, "report_title", teamName)
+	if len(reports) == 0 {
+		return text + "\n\n" + GetFormatText(texts, "report_empty")
+	}
+
+	for _, report := range reports {
+		week, prev := report.Week, report.Prev
+		text += "\n\n" + GetFormatText(texts, "report_member",
+			report.Login,
+			week.Done, delta(week.Done-prev.Done),
+			week.Overdue, delta(week.Overdue-prev.Overdue),
+			week.OpenPoints, delta(week.OpenPoints-prev.OpenPoints),
+			hours(week.Lateness), hoursDelta(week.Lateness-prev.Lateness))
+	}
+
+	return text
+}
+
+// FormatReportSchedule describes when the weekly report is sent.
+func FormatReportSchedule(texts map[string]string, schedule *model.ReportSchedule) string {
+	if schedule.Day == 0 {
+		return GetFormatText(texts, "report_schedule_off")
+	}
+
+	return GetFormatText(texts, "report_schedule",
+		GetFormatText(texts, "weekday_"+strconv.Itoa(schedule.Day%7)),
+		schedule.Time,
+		schedule.TimeZone)
+}
+
+func delta(d int) string {
+	switch {
+	case d > 0:
+		return "+" + strconv.Itoa(d)
+	case d < 0:
+		return "−" + strconv.Itoa(-d)
+	default:
+		return "±0"
+	}
+}
+
+func hours(d time.Duration) string {
+	return fmt.Sprintf("%.1f", d.Hours())
+}
+
+func hoursDelta(d time.Duration) string {
+	switch {
+	case d > 0:
+		return "+" + hours(d)
+	case d < 0:
+		return "−" + hours(-d)
+	default:
+		return "±0"
+	}
+}
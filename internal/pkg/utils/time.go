@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+var ErrInvalidTimeZone = errors.New("time zone must be an IANA name or a whole-hour UTC offset")
+
+// languageTimeZones maps Telegram language codes to the most likely time zone
+// of their speakers. It is only a first guess made during sign up.
+var languageTimeZones = map[string]string{
+	"ru": "Europe/Moscow",
+	"uk": "Europe/Kyiv",
+	"be": "Europe/Minsk",
+	"kk": "Asia/Almaty",
+	"uz": "Asia/Tashkent",
+	"hy": "Asia/Yerevan",
+	"ka": "Asia/Tbilisi",
+	"de": "Europe/Berlin",
+	"fr": "Europe/Paris",
+	"es": "Europe/Madrid",
+	"it": "Europe/Rome",
+	"pl": "Europe/Warsaw",
+	"tr": "Europe/Istanbul",
+}
+
+// GuessTimeZone infers a time zone from a Telegram language code such as "ru" or "en-GB".
+func GuessTimeZone(languageCode string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	if tz, ok := languageTimeZones[lang]; ok {
+		return tz
+	}
+
+	return model.DefaultTimeZone
+}
+
+// ParseTimeZone accepts an IANA name ("Europe/Moscow") or a whole-hour UTC
+// offset ("+3", "UTC-5") and returns the IANA name to store.
+func ParseTimeZone(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if _, err := time.LoadLocation(text); err == nil && text != "" && text != "Local" {
+		return text, nil
+	}
+
+	offset := strings.TrimPrefix(strings.TrimPrefix(strings.ToUpper(text), "UTC"), "GMT")
+	if offset == "" {
+		return model.DefaultTimeZone, nil
+	}
+
+	hours, err := strconv.Atoi(offset)
+	if err != nil || hours < -12 || hours > 14 {
+		return "", ErrInvalidTimeZone
+	}
+
+	if hours == 0 {
+		return model.DefaultTimeZone, nil
+	}
+
+	// Etc/GMT zones use the POSIX sign, so UTC+3 is Etc/GMT-3.
+	return fmt.Sprintf("Etc/GMT%+d", -hours), nil
+}
+
+// FormatDeadline prints t in loc like "пт, 23 окт 2026, 18:00 MSK" using the
+// weekday and month names of texts.
+func FormatDeadline(texts map[string]string, t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return GetFormatText(texts, "no_deadline")
+	}
+
+	t = t.In(loc)
+	return GetFormatText(texts, "deadline_format",
+		GetFormatText(texts, "weekday_"+strconv.Itoa(int(t.Weekday()))),
+		t.Day(),
+		GetFormatText(texts, "month_"+strconv.Itoa(int(t.Month()))),
+		t.Year(),
+		t.Format("15:04 MST"))
+}
+
+// FormatDuration prints d rounded to minutes like "1 ч 30 мин".
+func FormatDuration(texts map[string]string, d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+
+	switch {
+	case hours == 0:
+		return GetFormatText(texts, "duration_minutes", minutes)
+	case minutes == 0:
+		return GetFormatText(texts, "duration_hours", hours)
+	}
+
+	return GetFormatText(texts, "duration_hours", hours) + " " + GetFormatText(texts, "duration_minutes", minutes)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidComplexity  = errors.New("complexity must be a number from 1 to 10")
+	ErrInvalidDescription = errors.New("description must not be empty")
+)
+
+func ParseComplexity(text string) (int, error) {
+	complexity, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || complexity < 1 || complexity > 10 {
+		return 0, ErrInvalidComplexity
+	}
+
+	return complexity, nil
+}
+
+func ParseDescription(text string) (string, error) {
+	description := strings.TrimSpace(text)
+	if description == "" {
+		return "", ErrInvalidDescription
+	}
+
+	return description, nil
+}
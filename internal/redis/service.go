@@ -1,11 +1,14 @@
 package redis
 
 import (
+	"encoding/json"
 	"strconv"
 	"time"
 
 	"github.com/go-redis/redis"
 	"go.uber.org/zap"
+
+	"tgbot/internal/model"
 )
 
 const EmptyLogin = "not saved login"
@@ -87,3 +90,205 @@ func GetLogin(logger *zap.Logger, rdb *redis.Client, userID int64) string {
 	}
 	return value
 }
+
+func SetTaskID(logger *zap.Logger, rdb *redis.Client, userID int64, taskID int) {
+	id := strconv.FormatInt(userID, 10)
+	res := rdb.Set("task_id_"+id, strconv.Itoa(taskID), 7*24*time.Hour)
+	if res.Err() != nil {
+		logger.Error("set task id", zap.Error(res.Err()))
+	}
+}
+
+func GetTaskID(logger *zap.Logger, rdb *redis.Client, userID int64) string {
+	id := strconv.FormatInt(userID, 10)
+	have, err := rdb.Exists("task_id_" + id).Result()
+	if err != nil {
+		logger.Error("task id exists", zap.Error(err))
+	}
+	if have == 0 {
+		return EmptyLogin
+	}
+
+	value, err := rdb.Get("task_id_" + id).Result()
+	if err != nil {
+		logger.Error("get task id", zap.Error(err))
+	}
+
+	return value
+}
+
+func SetCardMessageID(logger *zap.Logger, rdb *redis.Client, userID int64, messageID int) {
+	id := strconv.FormatInt(userID, 10)
+	res := rdb.Set("card_"+id, strconv.Itoa(messageID), 7*24*time.Hour)
+	if res.Err() != nil {
+		logger.Error("set card message id", zap.Error(res.Err()))
+	}
+}
+
+func GetCardMessageID(logger *zap.Logger, rdb *redis.Client, userID int64) string {
+	id := strconv.FormatInt(userID, 10)
+	have, err := rdb.Exists("card_" + id).Result()
+	if err != nil {
+		logger.Error("card message id exists", zap.Error(err))
+	}
+	if have == 0 {
+		return EmptyLogin
+	}
+
+	value, err := rdb.Get("card_" + id).Result()
+	if err != nil {
+		logger.Error("get card message id", zap.Error(err))
+	}
+
+	return value
+}
+
+func SetEditField(logger *zap.Logger, rdb *redis.Client, userID int64, field string) {
+	id := strconv.FormatInt(userID, 10)
+	res := rdb.Set("edit_field_"+id, field, 7*24*time.Hour)
+	if res.Err() != nil {
+		logger.Error("set edit field", zap.Error(res.Err()))
+	}
+}
+
+func GetEditField(logger *zap.Logger, rdb *redis.Client, userID int64) string {
+	id := strconv.FormatInt(userID, 10)
+	have, err := rdb.Exists("edit_field_" + id).Result()
+	if err != nil {
+		logger.Error("edit field exists", zap.Error(err))
+	}
+	if have == 0 {
+		return EmptyLogin
+	}
+
+	value, err := rdb.Get("edit_field_" + id).Result()
+	if err != nil {
+		logger.Error("get edit field", zap.Error(err))
+	}
+
+	return value
+}
+
+// SetTaskFilter remembers the last used task filter of the user without expiration.
+func SetTaskFilter(logger *zap.Logger, rdb *redis.Client, userID int64, f *model.TaskFilter) {
+	id := strconv.FormatInt(userID, 10)
+	val, err := json.Marshal(f)
+	if err != nil {
+		logger.Error("marshal task filter", zap.Error(err))
+		return
+	}
+
+	res := rdb.Set("filter_"+id, val, 0)
+	if res.Err() != nil {
+		logger.Error("set task filter", zap.Error(res.Err()))
+	}
+}
+
+// GetTaskFilter returns the last used task filter of the user or the default one.
+func GetTaskFilter(logger *zap.Logger, rdb *redis.Client, userID int64) *model.TaskFilter {
+	id := strconv.FormatInt(userID, 10)
+	value, err := rdb.Get("filter_" + id).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Error("get task filter", zap.Error(err))
+		}
+		return model.DefaultTaskFilter(userID)
+	}
+
+	f := &model.TaskFilter{}
+	err = json.Unmarshal(value, f)
+	if err != nil {
+		logger.Error("unmarshal task filter", zap.Error(err))
+		return model.DefaultTaskFilter(userID)
+	}
+
+	return f
+}
+
+func SetSearchQuery(logger *zap.Logger, rdb *redis.Client, userID int64, query string) {
+	id := strconv.FormatInt(userID, 10)
+	res := rdb.Set("search_"+id, query, 7*24*time.Hour)
+	if res.Err() != nil {
+		logger.Error("set search query", zap.Error(res.Err()))
+	}
+}
+
+func GetSearchQuery(logger *zap.Logger, rdb *redis.Client, userID int64) string {
+	id := strconv.FormatInt(userID, 10)
+	value, err := rdb.Get("search_" + id).Result()
+	if err != nil && err != redis.Nil {
+		logger.Error("get search query", zap.Error(err))
+	}
+
+	return value
+}
+
+// SetPendingDeadline keeps the deadline entered for the task until the user
+// confirms it. Each task has its own, so creating one task while editing
+// another does not mix them up.
+func SetPendingDeadline(logger *zap.Logger, rdb *redis.Client, userID int64, taskID int, deadline time.Time) {
+	res := rdb.Set(pendingDeadlineKey(userID, taskID), deadline.Format(time.RFC3339), 7*24*time.Hour)
+	if res.Err() != nil {
+		logger.Error("set pending deadline", zap.Error(res.Err()))
+	}
+}
+
+func GetPendingDeadline(logger *zap.Logger, rdb *redis.Client, userID int64, taskID int) (time.Time, error) {
+	value, err := rdb.Get(pendingDeadlineKey(userID, taskID)).Result()
+	if err != nil {
+		logger.Error("get pending deadline", zap.Error(err))
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func pendingDeadlineKey(userID int64, taskID int) string {
+	return "deadline_" + strconv.FormatInt(userID, 10) + "_" + strconv.Itoa(taskID)
+}
+
+// SetImportRows keeps the validated rows of an import until it is confirmed.
+func SetImportRows(logger *zap.Logger, rdb *redis.Client, userID int64, rows []*model.ImportRow) {
+	id := strconv.FormatInt(userID, 10)
+	val, err := json.Marshal(rows)
+	if err != nil {
+		logger.Error("marshal import rows", zap.Error(err))
+		return
+	}
+
+	res := rdb.Set("import_"+id, val, time.Hour)
+	if res.Err() != nil {
+		logger.Error("set import rows", zap.Error(res.Err()))
+	}
+}
+
+// TakeImportRows returns the rows of the pending import and forgets them, so
+// an import is confirmed only once.
+func TakeImportRows(logger *zap.Logger, rdb *redis.Client, userID int64) []*model.ImportRow {
+	id := strconv.FormatInt(userID, 10)
+	value, err := rdb.Get("import_" + id).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Error("get import rows", zap.Error(err))
+		}
+		return nil
+	}
+
+	deleted, err := rdb.Del("import_" + id).Result()
+	if err != nil {
+		logger.Error("delete import rows", zap.Error(err))
+		return nil
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	var rows []*model.ImportRow
+	err = json.Unmarshal(value, &rows)
+	if err != nil {
+		logger.Error("unmarshal import rows", zap.Error(err))
+		return nil
+	}
+
+	return rows
+}
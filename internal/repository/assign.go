@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+// TeamPolicy returns the assignment policy of the team and the member the last
+// task was assigned to, 0 when there was none.
+func (r *PGRepository) TeamPolicy(teamID int) (policy string, lastID int64, err error) {
+	err = r.db.QueryRow(`SELECT assign_policy, COALESCE(last_assignee_id, 0) FROM bot.team WHERE id = $1`, teamID).Scan(&policy, &lastID)
+	if err != nil {
+		return "", 0, fmt.Errorf("execute query: %w", err)
+	}
+
+	return policy, lastID, nil
+}
+
+func (r *PGRepository) UpdateTeamPolicy(teamID int, policy string) error {
+	_, err := r.exec(`UPDATE bot.team SET assign_policy = $1 WHERE id = $2`, policy, teamID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateLastAssignee moves the round-robin position of the team to the user.
+func (r *PGRepository) UpdateLastAssignee(teamID int, userID int64) error {
+	_, err := r.exec(`UPDATE bot.team SET last_assignee_id = $1 WHERE id = $2`, userID, teamID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAvailable marks the user as available for new tasks of their team or not.
+func (r *PGRepository) UpdateAvailable(userID int64, available bool) error {
+	_, err := r.exec(`UPDATE bot.user_team SET available = $1 WHERE user_id = $2`, available, userID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// MemberLoads returns the members of the team with the sum of complexity of
+// their open tasks in the team.
+func (r *PGRepository) MemberLoads(teamID int) ([]*model.MemberLoad, error) {
+	rows, err := r.db.Query(`SELECT ut.user_id, COALESCE(u.login, ''), ut.available, COALESCE(SUM(t.complexity), 0)
+		FROM bot.user_team ut
+		LEFT JOIN bot.user u ON u.id = ut.user_id
+		LEFT JOIN bot.task t ON t.user_id = ut.user_id AND t.team_id = ut.team_id AND t.status = $2 AND t.deleted_at IS NULL
+		WHERE ut.team_id = $1
+		GROUP BY ut.user_id, u.login, ut.available
+		ORDER BY ut.user_id`, teamID, model.TaskOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*model.MemberLoad
+	for rows.Next() {
+		member := &model.MemberLoad{}
+		err = rows.Scan(&member.UserID, &member.Login, &member.Available, &member.Load)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// IsTeamMember reports whether the user belongs to the team.
+func (r *PGRepository) IsTeamMember(teamID int, userID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM bot.user_team WHERE team_id = $1 AND user_id = $2)`, teamID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("execute query: %w", err)
+	}
+
+	return exists, nil
+}
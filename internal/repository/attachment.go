@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+const attachmentColumns = `id, task_id, COALESCE(user_id, 0), kind, file_id, file_unique_id, file_name, mime_type, file_size, duration, created_at`
+
+func (r *PGRepository) AddAttachment(attachment *model.Attachment) error {
+	_, err := r.exec(`INSERT INTO bot.task_attachment (task_id, user_id, kind, file_id, file_unique_id, file_name, mime_type, file_size, duration)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		attachment.TaskID,
+		attachment.UserID,
+		attachment.Kind,
+		attachment.FileID,
+		attachment.FileUniqueID,
+		attachment.FileName,
+		attachment.MimeType,
+		attachment.FileSize,
+		attachment.Duration)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) GetAttachment(id int) (*model.Attachment, error) {
+	return scanAttachment(r.db.QueryRow(`SELECT `+attachmentColumns+` FROM bot.task_attachment WHERE id = $1`, id))
+}
+
+func (r *PGRepository) GetAttachments(taskID int) ([]*model.Attachment, error) {
+	rows, err := r.db.Query(`SELECT `+attachmentColumns+` FROM bot.task_attachment WHERE task_id = $1 ORDER BY created_at, id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return AttachmentRows(rows)
+}
+
+func scanAttachment(row scanner) (*model.Attachment, error) {
+	attachment := &model.Attachment{}
+	err := row.Scan(&attachment.ID,
+		&attachment.TaskID,
+		&attachment.UserID,
+		&attachment.Kind,
+		&attachment.FileID,
+		&attachment.FileUniqueID,
+		&attachment.FileName,
+		&attachment.MimeType,
+		&attachment.FileSize,
+		&attachment.Duration,
+		&attachment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func AttachmentRows(rows *sql.Rows) ([]*model.Attachment, error) {
+	defer rows.Close()
+
+	var attachments []*model.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"tgbot/internal/model"
+)
+
+// maxAuditEntries caps the number of audit entries returned at once.
+const maxAuditEntries = 500
+
+// As returns the repository recording userID as the actor of its writes in
+// the audit log. Writes of the repository itself are recorded without actor,
+// as done by the bot.
+func (r *PGRepository) As(userID int64) *PGRepository {
+	return &PGRepository{db: r.db, actor: userID}
+}
+
+// begin starts a transaction that the audit triggers attribute to the actor.
+func (r *PGRepository) begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.actor != 0 {
+		_, err = tx.ExecContext(ctx, `SELECT set_config('bot.actor', $1, true)`, strconv.FormatInt(r.actor, 10))
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
+// exec executes a write on behalf of the actor.
+func (r *PGRepository) exec(query string, args ...any) (sql.Result, error) {
+	if r.actor == 0 {
+		return r.db.Exec(query, args...)
+	}
+
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, tx.Commit()
+}
+
+// scanReturning executes a write returning a row on behalf of the actor and
+// scans the row into dest.
+func (r *PGRepository) scanReturning(query string, args []any, dest ...any) error {
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AuditLog returns the audit entries matching the query, newest first.
+func (r *PGRepository) AuditLog(q *model.AuditQuery) ([]*model.AuditEntry, error) {
+	var (
+		args  []any
+		where = []string{"true"}
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if q.TeamID != 0 {
+		where = append(where, `a.team_id = `+arg(q.TeamID))
+	}
+	if q.TaskID != 0 {
+		where = append(where, `a.task_id = `+arg(q.TaskID))
+	}
+	if q.ActorID != 0 {
+		where = append(where, `a.actor_id = `+arg(q.ActorID))
+	}
+	if q.Target != "" {
+		where = append(where, `a.target = `+arg(q.Target))
+	}
+	if !q.Since.IsZero() {
+		where = append(where, `a.created_at >= `+arg(q.Since))
+	}
+	if !q.Until.IsZero() {
+		where = append(where, `a.created_at < `+arg(q.Until))
+	}
+	if q.BeforeID != 0 {
+		where = append(where, `a.id < `+arg(q.BeforeID))
+	}
+
+	limit := q.Limit
+	if limit <= 0 || limit > maxAuditEntries {
+		limit = maxAuditEntries
+	}
+
+	rows, err := r.db.Query(`SELECT a.id, COALESCE(a.actor_id, 0), COALESCE(u.login, ''), a.action, a.target, COALESCE(a.target_id, ''),
+		COALESCE(a.task_id, 0), COALESCE(a.team_id, 0), COALESCE(a.before, 'null'), COALESCE(a.after, 'null'), a.created_at
+		FROM bot.audit_log a LEFT JOIN bot.user u ON u.id = a.actor_id
+		WHERE `+strings.Join(where, " AND ")+` ORDER BY a.id DESC LIMIT `+arg(limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.AuditEntry
+	for rows.Next() {
+		entry := &model.AuditEntry{}
+		var before, after []byte
+		err = rows.Scan(&entry.ID,
+			&entry.ActorID,
+			&entry.ActorLogin,
+			&entry.Action,
+			&entry.Target,
+			&entry.TargetID,
+			&entry.TaskID,
+			&entry.TeamID,
+			&before,
+			&after,
+			&entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+// TeamBacklog returns the open tasks of the team nobody is assigned to.
+func (r *PGRepository) TeamBacklog(teamID int) ([]*model.Tasks, error) {
+	rows, err := r.db.Query(`SELECT `+taskColumns+` FROM bot.task
+		WHERE team_id = $1 AND user_id IS NULL AND status = $2 AND `+notDeleted+`
+		ORDER BY deadline NULLS LAST, id`, teamID, model.TaskOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	return TaskRows(rows)
+}
+
+// ClaimTask assigns a backlog task to a member of its team. The update only
+// matches while the task is unassigned, so of two members claiming the same
+// task at once only one succeeds; claimed reports whether it was this one.
+func (r *PGRepository) ClaimTask(taskID int, userID int64) (claimed bool, err error) {
+	res, err := r.exec(`UPDATE bot.task SET user_id = $2, assigned_at = now()
+		WHERE id = $1 AND user_id IS NULL AND status = $3 AND `+notDeleted+`
+		AND team_id IN (SELECT team_id FROM bot.user_team WHERE user_id = $2)`, taskID, userID, model.TaskOpen)
+	if err != nil {
+		return false, fmt.Errorf("execute: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+// CalendarTasks returns the open tasks of the user that have a deadline.
+func (r *PGRepository) CalendarTasks(userID int64) ([]*model.Tasks, error) {
+	rows, err := r.db.Query(`SELECT `+taskColumns+` FROM bot.task
+		WHERE user_id = $1 AND status = $2 AND deadline IS NOT NULL AND `+notDeleted+` ORDER BY deadline, id`, userID, model.TaskOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	return TaskRows(rows)
+}
+
+// GetCalendarToken returns the secret of the calendar feed of the user, "" when
+// the user has none yet.
+func (r *PGRepository) GetCalendarToken(userID int64) (string, error) {
+	var token sql.NullString
+	err := r.db.QueryRow(`SELECT calendar_token FROM bot.user WHERE id = $1`, userID).Scan(&token)
+	if err != nil {
+		return "", fmt.Errorf("execute query: %w", err)
+	}
+
+	return token.String, nil
+}
+
+// UpdateCalendarToken replaces the secret of the calendar feed, revoking the
+// links given out before.
+func (r *PGRepository) UpdateCalendarToken(userID int64, token string) error {
+	_, err := r.exec(`UPDATE bot.user SET calendar_token = $1 WHERE id = $2`, token, userID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// CalendarUser returns the user the calendar feed secret belongs to, or nil
+// for an unknown secret.
+func (r *PGRepository) CalendarUser(token string) (*model.User, error) {
+	user := &model.User{}
+	err := r.db.QueryRow(`SELECT id, login, time_zone FROM bot.user WHERE calendar_token = $1`, token).Scan(&user.ID, &user.Login, &user.TimeZone)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+
+	return user, nil
+}
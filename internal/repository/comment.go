@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+func (r *PGRepository) AddComment(comment *model.Comment) error {
+	_, err := r.exec(`INSERT INTO bot.task_comment (task_id, user_id, text) VALUES ($1, $2, $3)`,
+		comment.TaskID,
+		comment.UserID,
+		comment.Text)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) GetComments(taskID int) ([]*model.Comment, error) {
+	rows, err := r.db.Query(`SELECT c.id, c.task_id, c.user_id, COALESCE(u.login, ''), c.text, c.created_at
+		FROM bot.task_comment c LEFT JOIN bot.user u ON u.id = c.user_id
+		WHERE c.task_id = $1 ORDER BY c.created_at, c.id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		err = rows.Scan(&comment.ID,
+			&comment.TaskID,
+			&comment.UserID,
+			&comment.Login,
+			&comment.Text,
+			&comment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *PGRepository) AddWatcher(taskID int, userID int64) error {
+	_, err := r.exec(`INSERT INTO bot.task_watcher (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) RemoveWatcher(taskID int, userID int64) error {
+	_, err := r.exec(`DELETE FROM bot.task_watcher WHERE task_id = $1 AND user_id = $2`, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) IsWatcher(taskID int, userID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM bot.task_watcher WHERE task_id = $1 AND user_id = $2)`, taskID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("execute query: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetParticipants returns the creator, the assignee and the watchers of the task.
+func (r *PGRepository) GetParticipants(taskID int) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM bot.task WHERE id = $1 AND user_id IS NOT NULL
+		UNION SELECT creator_id FROM bot.task WHERE id = $1 AND creator_id IS NOT NULL
+		UNION SELECT user_id FROM bot.task_watcher WHERE task_id = $1`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
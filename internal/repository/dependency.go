@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+var (
+	// ErrDependencyCycle is returned when the blocker already waits for the task.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrDifferentTeams is returned when the tasks do not belong to the same team.
+	ErrDifferentTeams = errors.New("tasks of different teams")
+)
+
+// DependencyError tells which blocker was rejected. Err is ErrDependencyCycle,
+// ErrDifferentTeams, ErrTaskDeleted for a task in the trash or sql.ErrNoRows
+// for a task that does not exist.
+type DependencyError struct {
+	BlockerID int
+	Err       error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("blocker %d: %v", e.BlockerID, e.Err)
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// AddDependencies marks the task as blocked by all of blockers or, when one
+// of them is rejected with a *DependencyError, by none. They are added under a
+// table lock so concurrent additions cannot close a cycle.
+func (r *PGRepository) AddDependencies(taskID int, blockerIDs []int) error {
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	_, err = tx.ExecContext(ctx, `LOCK TABLE bot.task_dependency IN SHARE ROW EXCLUSIVE MODE`)
+	if err != nil {
+		return err
+	}
+
+	for _, blockerID := range blockerIDs {
+		err = addDependency(ctx, tx, taskID, blockerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func addDependency(ctx context.Context, tx *sql.Tx, taskID, blockerID int) error {
+	if taskID == blockerID {
+		return &DependencyError{BlockerID: blockerID, Err: ErrDependencyCycle}
+	}
+
+	var sameTeam, deleted bool
+	err := tx.QueryRowContext(ctx, `SELECT t.team_id IS NOT NULL AND t.team_id = b.team_id, b.deleted_at IS NOT NULL
+		FROM bot.task t, bot.task b WHERE t.id = $1 AND b.id = $2`, taskID, blockerID).Scan(&sameTeam, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &DependencyError{BlockerID: blockerID, Err: err}
+	}
+	if err != nil {
+		return fmt.Errorf("check teams: %w", err)
+	}
+
+	if !sameTeam {
+		return &DependencyError{BlockerID: blockerID, Err: ErrDifferentTeams}
+	}
+
+	if deleted {
+		return &DependencyError{BlockerID: blockerID, Err: ErrTaskDeleted}
+	}
+
+	var cycle bool
+	err = tx.QueryRowContext(ctx, `WITH RECURSIVE blockers(id) AS (
+			SELECT blocker_id FROM bot.task_dependency WHERE task_id = $2
+			UNION
+			SELECT d.blocker_id FROM bot.task_dependency d JOIN blockers ON d.task_id = blockers.id
+		)
+		SELECT EXISTS(SELECT 1 FROM blockers WHERE id = $1)`, taskID, blockerID).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("check cycle: %w", err)
+	}
+
+	if cycle {
+		return &DependencyError{BlockerID: blockerID, Err: ErrDependencyCycle}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO bot.task_dependency (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) RemoveDependency(taskID, blockerID int) error {
+	_, err := r.exec(`DELETE FROM bot.task_dependency WHERE task_id = $1 AND blocker_id = $2`, taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// UnblockedBy returns the open tasks that waited for the finished task and
+// have no unfinished blockers left.
+func (r *PGRepository) UnblockedBy(taskID int) ([]*model.Tasks, error) {
+	rows, err := r.db.Query(`SELECT `+taskColumns+` FROM bot.task
+		WHERE id IN (SELECT task_id FROM bot.task_dependency WHERE blocker_id = $1)
+		AND status = $2 AND `+notDeleted+` AND NOT EXISTS (`+openBlockers+`)`, taskID, model.TaskOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	return TaskRows(rows)
+}
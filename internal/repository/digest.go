@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+// digestDue matches the users of the outer query whose digest time of the day
+// has come in their time zone and who have not got a digest today.
+const digestDue = `digest_time IS NOT NULL
+	AND (now() AT TIME ZONE time_zone)::time >= digest_time
+	AND (digest_sent_at IS NULL OR (digest_sent_at AT TIME ZONE time_zone)::date < (now() AT TIME ZONE time_zone)::date)`
+
+// GetDigestTime returns the time of day the user gets the digest at, "" when off.
+func (r *PGRepository) GetDigestTime(userID int64) (string, error) {
+	var at sql.NullString
+	err := r.db.QueryRow(`SELECT to_char(digest_time, 'HH24:MI') FROM bot.user WHERE id = $1`, userID).Scan(&at)
+	if err != nil {
+		return "", fmt.Errorf("execute query: %w", err)
+	}
+
+	return at.String, nil
+}
+
+// UpdateDigestTime turns the digest on at the given "HH:MM" or off for "".
+func (r *PGRepository) UpdateDigestTime(userID int64, at string) error {
+	_, err := r.exec(`UPDATE bot.user SET digest_time = NULLIF($1, '')::time WHERE id = $2`, at, userID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) DueDigests() ([]*model.DigestUser, error) {
+	rows, err := r.db.Query(`SELECT id, time_zone, digest_sent_at FROM bot.user WHERE ` + digestDue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*model.DigestUser
+	for rows.Next() {
+		user := &model.DigestUser{}
+		var sent sql.NullTime
+		err = rows.Scan(&user.UserID, &user.TimeZone, &sent)
+		if err != nil {
+			return nil, err
+		}
+
+		user.LastSent = sent.Time
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// MarkDigestSent records the digest of the user as sent today. It reports false
+// when another run has already done so.
+func (r *PGRepository) MarkDigestSent(userID int64) (bool, error) {
+	res, err := r.exec(`UPDATE bot.user SET digest_sent_at = now() WHERE id = $1 AND `+digestDue, userID)
+	if err != nil {
+		return false, fmt.Errorf("execute: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// RestoreDigestSent takes back the mark of MarkDigestSent when the digest could
+// not be sent, putting back the time the last one was sent.
+func (r *PGRepository) RestoreDigestSent(userID int64, lastSent time.Time) error {
+	_, err := r.exec(`UPDATE bot.user SET digest_sent_at = $2 WHERE id = $1`, userID, sql.NullTime{Time: lastSent, Valid: !lastSent.IsZero()})
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// Digest collects the open tasks of the user that are overdue, due today in
+// their time zone, or assigned to them by someone else after since.
+func (r *PGRepository) Digest(userID int64, since time.Time) (*model.Digest, error) {
+	rows, err := r.db.Query(`SELECT `+taskColumns+`, CASE
+			WHEN deadline < now() THEN 'overdue'
+			WHEN (deadline AT TIME ZONE `+userTimeZone+`)::date = (now() AT TIME ZONE `+userTimeZone+`)::date THEN 'today'
+			WHEN assigned_at > $3 AND creator_id IS DISTINCT FROM user_id THEN 'assigned'
+			ELSE '' END
+		FROM bot.task
+		WHERE user_id = $1 AND status = $2 AND `+notDeleted+`
+		ORDER BY `+pressingOrder, userID, model.TaskOpen, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	digest := &model.Digest{}
+	for rows.Next() {
+		var kind string
+		task, err := scanTask(rows, &kind)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case "overdue":
+			digest.Overdue = append(digest.Overdue, task)
+		case "today":
+			digest.Today = append(digest.Today, task)
+		case "assigned":
+			digest.Assigned = append(digest.Assigned, task)
+		}
+	}
+
+	return digest, rows.Err()
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"tgbot/internal/model"
+)
+
+// visibleTask limits a task query to the tasks user $1 is assigned to, created
+// or shares a team with, leaving out deleted ones.
+const visibleTask = `(` + notDeleted + ` AND (user_id = $1 OR creator_id = $1 OR team_id IN (SELECT team_id FROM bot.user_team WHERE user_id = $1)))`
+
+// userTimeZone is the time zone of user $1 that calendar days are counted in.
+const userTimeZone = `(SELECT time_zone FROM bot.user WHERE id = $1)`
+
+var taskSorts = map[string]string{
+	model.SortDeadline:   `deadline NULLS LAST, id`,
+	model.SortComplexity: `complexity DESC NULLS LAST, id`,
+	model.SortCreated:    `created_at DESC, id DESC`,
+	model.SortPressing:   pressingOrder,
+}
+
+// FilterTasks returns the tasks visible to userID that match the filter. A task
+// is visible when the user is its assignee or creator or a member of its team.
+func (r *PGRepository) FilterTasks(userID int64, f *model.TaskFilter) ([]*model.Tasks, error) {
+	query, args := filterTasksQuery(userID, f)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return TaskRows(rows)
+}
+
+func filterTasksQuery(userID int64, f *model.TaskFilter) (string, []any) {
+	args := []any{userID, model.TaskDraft}
+	where := []string{
+		visibleTask,
+		`status <> $2`,
+	}
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.Status != "" && f.Status != model.FilterAll {
+		where = append(where, `status = `+arg(f.Status))
+	}
+
+	switch f.Due {
+	case model.DueOverdue:
+		where = append(where, `deadline < now() AND status <> `+arg(model.TaskDone))
+	case model.DueToday:
+		where = append(where, `(deadline AT TIME ZONE `+userTimeZone+`)::date = (now() AT TIME ZONE `+userTimeZone+`)::date`)
+	case model.DueWeek:
+		where = append(where, `date_trunc('week', deadline AT TIME ZONE `+userTimeZone+`) = date_trunc('week', now() AT TIME ZONE `+userTimeZone+`)`)
+	}
+
+	if f.MinComplexity > 0 {
+		where = append(where, `complexity >= `+arg(f.MinComplexity))
+	}
+	if f.MaxComplexity > 0 {
+		where = append(where, `complexity <= `+arg(f.MaxComplexity))
+	}
+	if f.AssigneeID != 0 {
+		where = append(where, `user_id = `+arg(f.AssigneeID))
+	}
+	if f.CreatorID != 0 {
+		where = append(where, `creator_id = `+arg(f.CreatorID))
+	}
+	if f.TeamID != 0 {
+		where = append(where, `team_id = `+arg(f.TeamID))
+	}
+
+	if f.Label != "" {
+		where = append(where, hasLabels(arg(pq.Array([]string{f.Label}))))
+	}
+
+	if f.From != "" {
+		where = append(where, `(deadline AT TIME ZONE `+userTimeZone+`)::date >= `+arg(f.From)+`::date`)
+	}
+	if f.To != "" {
+		where = append(where, `(deadline AT TIME ZONE `+userTimeZone+`)::date <= `+arg(f.To)+`::date`)
+	}
+
+	order, ok := taskSorts[f.Sort]
+	if !ok {
+		order = taskSorts[model.SortDeadline]
+	}
+
+	return `SELECT ` + taskColumns + ` FROM bot.task WHERE ` + strings.Join(where, " AND ") + ` ORDER BY ` + order, args
+}
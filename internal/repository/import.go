@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"tgbot/internal/model"
+)
+
+// ErrImportAssignee is returned when an assignee left the team before the
+// import was confirmed.
+var ErrImportAssignee = errors.New("assignee is not a team member")
+
+// ImportTasks creates an open task of the team for every row in a single
+// transaction and returns their IDs in the order of the rows.
+func (r *PGRepository) ImportTasks(creatorID int64, teamID int, rows []*model.ImportRow) ([]int, error) {
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO bot.task (user_id, creator_id, team_id, status, complexity, deadline, description)
+		SELECT $1, $2, $3, $4, $5, $6, $7 WHERE EXISTS (SELECT 1 FROM bot.user_team WHERE user_id = $1 AND team_id = $3)
+		RETURNING id`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		var id int
+		err = stmt.QueryRowContext(ctx, row.AssigneeID, creatorID, teamID, model.TaskOpen, row.Complexity, row.Deadline, row.Description).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportAssignee
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, tx.Commit()
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"tgbot/internal/model"
+)
+
+// AddTaskItems appends items to the end of the checklist of the task in one statement.
+func (r *PGRepository) AddTaskItems(taskID int, items []string) error {
+	_, err := r.exec(`INSERT INTO bot.task_item (task_id, position, text)
+		SELECT $1, COALESCE((SELECT max(position) FROM bot.task_item WHERE task_id = $1), 0) + n, t
+		FROM unnest($2::text[]) WITH ORDINALITY AS u(t, n)`, taskID, pq.Array(items))
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) GetTaskItems(taskID int) ([]*model.TaskItem, error) {
+	rows, err := r.db.Query(`SELECT id, task_id, position, text, done FROM bot.task_item WHERE task_id = $1 ORDER BY position, id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*model.TaskItem
+	for rows.Next() {
+		item := &model.TaskItem{}
+		err = rows.Scan(&item.ID,
+			&item.TaskID,
+			&item.Position,
+			&item.Text,
+			&item.Done)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ToggleTaskItem flips an item of the task's checklist and reports whether it is done now.
+func (r *PGRepository) ToggleTaskItem(taskID, itemID int) (bool, error) {
+	var done bool
+	err := r.scanReturning(`UPDATE bot.task_item SET done = NOT done WHERE id = $1 AND task_id = $2 RETURNING done`, []any{itemID, taskID}, &done)
+	if err != nil {
+		return false, fmt.Errorf("execute: %w", err)
+	}
+
+	return done, nil
+}
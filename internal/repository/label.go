@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"tgbot/internal/model"
+)
+
+// hasLabels matches the tasks of the outer query carrying every label name in
+// the text array parameter. An empty array matches every task.
+func hasLabels(param string) string {
+	return `NOT EXISTS (SELECT 1 FROM unnest(` + param + `::text[]) AS want(name) WHERE want.name NOT IN (
+		SELECT l.name FROM bot.task_label tl JOIN bot.team_label l ON l.id = tl.label_id WHERE tl.task_id = task.id))`
+}
+
+func (r *PGRepository) TeamLabels(teamID int) ([]*model.Label, error) {
+	rows, err := r.db.Query(`SELECT id, team_id, name FROM bot.team_label WHERE team_id = $1 ORDER BY name`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []*model.Label
+	for rows.Next() {
+		label := &model.Label{}
+		err = rows.Scan(&label.ID, &label.TeamID, &label.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, label)
+	}
+
+	return labels, rows.Err()
+}
+
+func (r *PGRepository) AddTeamLabels(teamID int, names []string) error {
+	_, err := r.exec(`INSERT INTO bot.team_label (team_id, name) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING`, teamID, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PGRepository) RemoveTeamLabels(teamID int, names []string) error {
+	_, err := r.exec(`DELETE FROM bot.team_label WHERE team_id = $1 AND name = ANY($2)`, teamID, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// ToggleTaskLabel puts a label of the task's team on the task or takes it off.
+func (r *PGRepository) ToggleTaskLabel(taskID, labelID int) error {
+	res, err := r.exec(`DELETE FROM bot.task_label WHERE task_id = $1 AND label_id = $2`, taskID, labelID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+
+	_, err = r.exec(`INSERT INTO bot.task_label (task_id, label_id)
+		SELECT t.id, l.id FROM bot.task t JOIN bot.team_label l ON l.team_id = t.team_id
+		WHERE t.id = $1 AND l.id = $2`, taskID, labelID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"tgbot/internal/model"
+)
+
+// pressingOrder ranks tasks by how soon they need attention: the deadline is
+// moved earlier the higher the priority, so an urgent task due on Friday comes
+// before a low one due on Wednesday. Tasks without a deadline count as due in
+// a week.
+const pressingOrder = `COALESCE(deadline, now() + interval '7 days') - CASE priority
+		WHEN '` + model.PriorityUrgent + `' THEN interval '3 days'
+		WHEN '` + model.PriorityHigh + `' THEN interval '1 day'
+		WHEN '` + model.PriorityLow + `' THEN interval '-2 days'
+		ELSE interval '0' END, id`
+
+// NextTask returns the most pressing open task assigned to the user that does
+// not wait for other tasks, or nil when there is none.
+func (r *PGRepository) NextTask(userID int64) (*model.Tasks, error) {
+	task, err := TaskRow(r.db.QueryRow(`SELECT `+taskColumns+` FROM bot.task
+		WHERE user_id = $1 AND status = $2 AND `+notDeleted+` AND NOT EXISTS (`+openBlockers+`)
+		ORDER BY `+pressingOrder+` LIMIT 1`, userID, model.TaskOpen))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return task, err
+}
@@ -7,11 +7,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"tgbot/internal/model"
 )
 
 type PGRepository struct {
 	db *sql.DB
+	// actor is the user the writes are recorded for in the audit log.
+	actor int64
 }
 
 func NewPgRepository(db *sql.DB) *PGRepository {
@@ -42,12 +46,37 @@ func (r *PGRepository) CheckLogin(login string) (bool, error) {
 }
 
 func (r *PGRepository) AddNewUser(user *model.User) error {
-	_, err := r.db.Exec(`INSERT INTO bot.user(id, login, password, tg_name, tg_username, register_time) VALUES ($1,$2,$3,$4,$5,now())`,
+	_, err := r.exec(`INSERT INTO bot.user(id, login, password, tg_name, tg_username, time_zone, register_time) VALUES ($1,$2,$3,$4,$5,$6,now())`,
 		user.ID,
 		user.Login,
 		user.Password,
 		user.TgName,
-		user.TgUsername)
+		user.TgUsername,
+		user.TimeZone)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserTimeZone returns the time zone of the user or an empty string for an
+// unregistered user.
+func (r *PGRepository) GetUserTimeZone(id int64) (string, error) {
+	var tz string
+	err := r.db.QueryRow(`SELECT time_zone FROM bot.user WHERE id = $1`, id).Scan(&tz)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("execute: %w", err)
+	}
+
+	return tz, nil
+}
+
+func (r *PGRepository) UpdateUserTimeZone(id int64, tz string) error {
+	_, err := r.exec(`UPDATE bot.user SET time_zone = $1 WHERE id = $2`, tz, id)
 	if err != nil {
 		return fmt.Errorf("execute: %w", err)
 	}
@@ -55,8 +84,38 @@ func (r *PGRepository) AddNewUser(user *model.User) error {
 	return nil
 }
 
-func (r *PGRepository) AddUserToTaskBar(userID int64) error {
-	_, err := r.db.Exec(`INSERT INTO bot.task (user_id) VALUES ($1)`, userID)
+// AddUserToTaskBar creates a draft task. A userID of 0 puts it into the team
+// backlog without an assignee.
+func (r *PGRepository) AddUserToTaskBar(userID, creatorID int64, teamID int) (int, error) {
+	var taskID int
+	err := r.scanReturning(`INSERT INTO bot.task (user_id, creator_id, team_id, status) VALUES (NULLIF($1, 0), $2, $3, $4) RETURNING id`,
+		[]any{userID, creatorID, teamID, model.TaskDraft},
+		&taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return taskID, nil
+}
+
+func (r *PGRepository) UpdateTaskComplexity(taskID int, complexity int) error {
+	_, err := r.exec(`UPDATE bot.task SET complexity = $1 WHERE id = $2`, complexity, taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PGRepository) UpdateTaskDeadline(taskID int, deadline time.Time) error {
+	_, err := r.exec(`UPDATE bot.task SET deadline = $1 WHERE id = $2`, deadline, taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PGRepository) UpdateTaskPriority(taskID int, priority string) error {
+	_, err := r.exec(`UPDATE bot.task SET priority = $1 WHERE id = $2`, priority, taskID)
 	if err != nil {
 		return err
 	}
@@ -64,46 +123,59 @@ func (r *PGRepository) AddUserToTaskBar(userID int64) error {
 	return nil
 }
 
-func (r *PGRepository) UpdateTaskComplexity(userID int64, complexity int) error {
-	_, err := r.db.Exec(`UPDATE bot.task SET complexity = $1 WHERE user_id = $2`, complexity, userID)
+func (r *PGRepository) UpdateTaskStatus(taskID int, status string) error {
+	_, err := r.exec(`UPDATE bot.task SET status = $1::text,
+		done_at = CASE WHEN $1::text = '`+model.TaskDone+`' THEN now() END WHERE id = $2`, status, taskID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *PGRepository) UpdateTaskDeadline(userID int64, deadline time.Time) error {
-	_, err := r.db.Exec(`UPDATE bot.task SET deadline = $1 WHERE user_id = $2`, deadline, userID)
+func (r *PGRepository) UpdateTaskUser(taskID int, userID int64) error {
+	_, err := r.exec(`UPDATE bot.task SET user_id = $1, assigned_at = now() WHERE id = $2`, userID, taskID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *PGRepository) GetTaskInfo(userID int64) (*model.Tasks, error) {
-	task := &model.Tasks{}
-	err := r.db.QueryRow(`SELECT complexity, deadline, description FROM bot.task WHERE user_id = $1`, userID).Scan(
-		&task.Complexity,
-		&task.Deadline,
-		&task.Description)
+func (r *PGRepository) PostponeTask(taskID int, hours int) error {
+	_, err := r.exec(`UPDATE bot.task SET deadline = deadline + $1 * interval '1 hour' WHERE id = $2`, hours, taskID)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return nil
+}
 
-	return task, nil
+func (r *PGRepository) GetTaskInfo(taskID int) (*model.Tasks, error) {
+	row := r.db.QueryRow(`SELECT `+taskColumns+` FROM bot.task WHERE id = $1 AND `+notDeleted, taskID)
+
+	return TaskRow(row)
 }
 
+// DeleteTask moves the task to the trash. It can be restored until it is
+// purged. A task already there gives ErrTaskDeleted.
 func (r *PGRepository) DeleteTask(taskID int) error {
-	_, err := r.db.Exec(`DELETE FROM bot.task WHERE id = $1`, taskID)
+	res, err := r.exec(`UPDATE bot.task SET deleted_at = now() WHERE id = $1 AND `+notDeleted, taskID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if n == 0 {
+		return ErrTaskDeleted
+	}
 
 	return nil
 }
 
 func (r *PGRepository) GetTasksInfo(userID int64) ([]*model.Tasks, error) {
-	rows, err := r.db.Query(`SELECT id, complexity, deadline, description FROM bot.task WHERE user_id = $1`, userID)
+	rows, err := r.db.Query(`SELECT `+taskColumns+` FROM bot.task WHERE user_id = $1 AND status <> $2 AND `+notDeleted+`
+		ORDER BY status = '`+model.TaskDone+`', `+pressingOrder, userID, model.TaskDraft)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -114,14 +186,66 @@ func (r *PGRepository) GetTasksInfo(userID int64) ([]*model.Tasks, error) {
 	return TaskRows(rows)
 }
 
+const taskColumns = `id, COALESCE(user_id, 0), COALESCE(creator_id, 0), COALESCE(team_id, 0), COALESCE(series_id, 0), status, complexity, deadline, description,
+	(SELECT count(*) FROM bot.task_attachment a WHERE a.task_id = task.id),
+	(SELECT count(*) FROM bot.task_item i WHERE i.task_id = task.id),
+	(SELECT count(*) FROM bot.task_item i WHERE i.task_id = task.id AND i.done),
+	ARRAY(` + openBlockers + ` ORDER BY d.blocker_id),
+	ARRAY(SELECT l.name FROM bot.task_label tl JOIN bot.team_label l ON l.id = tl.label_id WHERE tl.task_id = task.id ORDER BY l.name),
+	priority`
+
+// openBlockers selects the unfinished tasks the task of the outer query waits for.
+const openBlockers = `SELECT d.blocker_id FROM bot.task_dependency d JOIN bot.task b ON b.id = d.blocker_id
+	WHERE d.task_id = task.id AND b.status <> '` + model.TaskDone + `' AND b.deleted_at IS NULL`
+
+// notDeleted leaves out the tasks in the trash waiting to be purged.
+const notDeleted = `deleted_at IS NULL`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask scans taskColumns followed by the extra columns of the query.
+func scanTask(row scanner, extra ...any) (*model.Tasks, error) {
+	task := &model.Tasks{}
+	var blockers pq.Int64Array
+	dest := []any{&task.ID,
+		&task.UserID,
+		&task.CreatorID,
+		&task.TeamID,
+		&task.SeriesID,
+		&task.Status,
+		&task.Complexity,
+		&task.Deadline,
+		&task.Description,
+		&task.Attachments,
+		&task.Items,
+		&task.ItemsDone,
+		&blockers,
+		(*pq.StringArray)(&task.Labels),
+		&task.Priority}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range blockers {
+		task.BlockedBy = append(task.BlockedBy, int(id))
+	}
+
+	return task, nil
+}
+
+func TaskRow(row *sql.Row) (*model.Tasks, error) {
+	return scanTask(row)
+}
+
 func TaskRows(rows *sql.Rows) ([]*model.Tasks, error) {
+	defer rows.Close()
+
 	var tasks []*model.Tasks
 	for rows.Next() {
-		task := &model.Tasks{}
-		err := rows.Scan(&task.ID,
-			&task.Complexity,
-			&task.Deadline,
-			&task.Description)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -129,11 +253,11 @@ func TaskRows(rows *sql.Rows) ([]*model.Tasks, error) {
 		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
+	return tasks, rows.Err()
 }
 
-func (r *PGRepository) UpdateTaskDescription(userID int64, description string) error {
-	_, err := r.db.Exec(`UPDATE bot.task SET description = $1 WHERE user_id = $2`, description, userID)
+func (r *PGRepository) UpdateTaskDescription(taskID int, description string) error {
+	_, err := r.exec(`UPDATE bot.task SET description = $1 WHERE id = $2`, description, taskID)
 	if err != nil {
 		return err
 	}
@@ -176,7 +300,7 @@ func (r *PGRepository) YourTeam(teamID int) (*model.Team, error) {
 }
 
 func (r *PGRepository) DeleteUserFromTeam(userID int64) error {
-	_, err := r.db.Exec(
+	_, err := r.exec(
 		`DELETE FROM bot.user_team WHERE user_id = $1`,
 		userID,
 	)
@@ -204,7 +328,7 @@ func UserRows(rows *sql.Rows) ([]*model.User, error) {
 
 func (r *PGRepository) CreateTeam(id int64, teamName string) error {
 	ctx := context.Background()
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -219,7 +343,7 @@ func (r *PGRepository) CreateTeam(id int64, teamName string) error {
 		return err
 	}
 
-	_, err = tx.ExecContext(ctx, `INSERT INTO bot.user_team (team_id, user_id) VALUES ($1, $2)`, teamId, id)
+	_, err = tx.ExecContext(ctx, `INSERT INTO bot.user_team (team_id, user_id, role) VALUES ($1, $2, $3)`, teamId, id, model.RoleAdmin)
 	if err != nil {
 		return err
 	}
@@ -228,7 +352,7 @@ func (r *PGRepository) CreateTeam(id int64, teamName string) error {
 }
 
 func (r *PGRepository) AddUserToTeam(teamID int, userID int64) (string, error) {
-	_, err := r.db.Exec(`INSERT INTO bot.user_team(team_id, user_id) VALUES ($1, $2)`, teamID, userID)
+	_, err := r.exec(`INSERT INTO bot.user_team(team_id, user_id) VALUES ($1, $2)`, teamID, userID)
 	if err != nil {
 		return "", fmt.Errorf("execute: %w", err)
 	}
@@ -241,3 +365,38 @@ func (r *PGRepository) AddUserToTeam(teamID int, userID int64) (string, error) {
 
 	return teamName, nil
 }
+
+func (r *PGRepository) TeamAdmins() ([]int64, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT user_id FROM bot.user_team WHERE role = $1`, model.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *PGRepository) AddTaskChange(change *model.TaskChange) error {
+	_, err := r.exec(`INSERT INTO bot.task_change (task_id, user_id, field, before, after) VALUES ($1, $2, $3, $4, $5)`,
+		change.TaskID,
+		change.UserID,
+		change.Field,
+		change.Before,
+		change.After)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+// GetActor returns the registration, team and role of the user.
+func (r *PGRepository) GetActor(userID int64) (*model.Actor, error) {
+	actor := &model.Actor{ID: userID}
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM bot.user WHERE id = $1),
+		COALESCE((SELECT team_id FROM bot.user_team WHERE user_id = $1 LIMIT 1), 0),
+		COALESCE((SELECT role FROM bot.user_team WHERE user_id = $1 LIMIT 1), '')`, userID).
+		Scan(&actor.Registered, &actor.TeamID, &actor.Role)
+	if err != nil {
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+
+	return actor, nil
+}
+
+// GetTaskParties returns the assignee, creator and team of the task, also when
+// it is in the trash, or nil when there is no such task.
+func (r *PGRepository) GetTaskParties(taskID int) (*model.TaskParties, error) {
+	parties := &model.TaskParties{}
+	err := r.db.QueryRow(`SELECT COALESCE(user_id, 0), COALESCE(creator_id, 0), COALESCE(team_id, 0), deleted_at IS NOT NULL
+		FROM bot.task WHERE id = $1`, taskID).Scan(&parties.UserID, &parties.CreatorID, &parties.TeamID, &parties.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+
+	return parties, nil
+}
+
+// GetInviteToken returns the secret of the invite link of the team, "" when
+// the team has none yet or does not exist.
+func (r *PGRepository) GetInviteToken(teamID int) (string, error) {
+	var token sql.NullString
+	err := r.db.QueryRow(`SELECT invite_token FROM bot.team WHERE id = $1`, teamID).Scan(&token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("execute query: %w", err)
+	}
+
+	return token.String, nil
+}
+
+// UpdateInviteToken replaces the secret of the invite link of the team.
+func (r *PGRepository) UpdateInviteToken(teamID int, token string) error {
+	_, err := r.exec(`UPDATE bot.team SET invite_token = $1 WHERE id = $2`, token, teamID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+// openAt matches tasks of the outer query that were open at the time parameter.
+// Done tasks without done_at were finished before it was recorded.
+func openAt(param string) string {
+	return `t.created_at < ` + param + ` AND t.status <> '` + model.TaskDraft + `'
+		AND (t.status <> '` + model.TaskDone + `' OR t.done_at >= ` + param + `)`
+}
+
+func doneIn(from, to string) string {
+	return `t.done_at >= ` + from + ` AND t.done_at < ` + to
+}
+
+func lateness(from, to string) string {
+	return `COALESCE(avg(EXTRACT(EPOCH FROM GREATEST(t.done_at - t.deadline, interval '0')))
+		FILTER (WHERE ` + doneIn(from, to) + ` AND t.deadline IS NOT NULL), 0)`
+}
+
+// WorkloadReport aggregates the tasks of each team member for the week ending
+// at now and the week before it.
+func (r *PGRepository) WorkloadReport(teamID int, now time.Time) ([]*model.MemberReport, error) {
+	const week = 7 * 24 * time.Hour
+	rows, err := r.db.Query(`SELECT ut.user_id, COALESCE(u.login, ''),
+		count(t.id) FILTER (WHERE `+doneIn("$2", "$3")+`),
+		count(t.id) FILTER (WHERE `+doneIn("$4", "$2")+`),
+		count(t.id) FILTER (WHERE `+openAt("$3")+` AND t.deadline < $3),
+		count(t.id) FILTER (WHERE `+openAt("$2")+` AND t.deadline < $2),
+		COALESCE(sum(t.complexity) FILTER (WHERE `+openAt("$3")+`), 0),
+		COALESCE(sum(t.complexity) FILTER (WHERE `+openAt("$2")+`), 0),
+		`+lateness("$2", "$3")+`,
+		`+lateness("$4", "$2")+`
+		FROM bot.user_team ut
+		LEFT JOIN bot.user u ON u.id = ut.user_id
+		LEFT JOIN bot.task t ON t.user_id = ut.user_id AND t.team_id = ut.team_id AND t.deleted_at IS NULL
+		WHERE ut.team_id = $1
+		GROUP BY ut.user_id, u.login
+		ORDER BY u.login, ut.user_id`, teamID, now.Add(-week), now, now.Add(-2*week))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*model.MemberReport
+	for rows.Next() {
+		report := &model.MemberReport{}
+		var lateness, prevLateness float64
+		err = rows.Scan(&report.UserID,
+			&report.Login,
+			&report.Week.Done,
+			&report.Prev.Done,
+			&report.Week.Overdue,
+			&report.Prev.Overdue,
+			&report.Week.OpenPoints,
+			&report.Prev.OpenPoints,
+			&lateness,
+			&prevLateness)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Week.Lateness = time.Duration(lateness * float64(time.Second))
+		report.Prev.Lateness = time.Duration(prevLateness * float64(time.Second))
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *PGRepository) GetReportSchedule(teamID int) (*model.ReportSchedule, error) {
+	schedule := &model.ReportSchedule{TeamID: teamID}
+	err := r.db.QueryRow(`SELECT COALESCE(report_day, 0), to_char(report_time, 'HH24:MI'), report_tz FROM bot.team WHERE id = $1`, teamID).
+		Scan(&schedule.Day, &schedule.Time, &schedule.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (r *PGRepository) UpdateReportSchedule(schedule *model.ReportSchedule) error {
+	_, err := r.exec(`UPDATE bot.team SET report_day = NULLIF($1, 0), report_time = $2::time, report_tz = $3 WHERE id = $4`,
+		schedule.Day,
+		schedule.Time,
+		schedule.TimeZone,
+		schedule.TeamID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// DueReports returns the teams whose report time of the day has come in the
+// report time zone and that have not got the report in the last day.
+func (r *PGRepository) DueReports() ([]int, error) {
+	rows, err := r.db.Query(`SELECT id FROM bot.team
+		WHERE report_day = EXTRACT(ISODOW FROM now() AT TIME ZONE report_tz)
+		AND (now() AT TIME ZONE report_tz)::time >= report_time
+		AND (report_sent_at IS NULL OR report_sent_at < now() - interval '1 day')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// MarkReportSent records that the report of the team is being sent. It reports
+// false when another run has already sent it.
+func (r *PGRepository) MarkReportSent(teamID int) (bool, error) {
+	res, err := r.exec(`UPDATE bot.team SET report_sent_at = now()
+		WHERE id = $1 AND (report_sent_at IS NULL OR report_sent_at < now() - interval '1 day')`, teamID)
+	if err != nil {
+		return false, fmt.Errorf("execute: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// ClearReportSent takes back the mark of MarkReportSent when the report could
+// not be delivered.
+func (r *PGRepository) ClearReportSent(teamID int) error {
+	_, err := r.exec(`UPDATE bot.team SET report_sent_at = NULL WHERE id = $1`, teamID)
+	if err != nil {
+		return fmt.Errorf("execute: %w", err)
+	}
+
+	return nil
+}
+
+// TeamAdminsOf returns the admins of the team.
+func (r *PGRepository) TeamAdminsOf(teamID int) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM bot.user_team WHERE team_id = $1 AND role = $2`, teamID, model.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// TeamName returns the name of the team.
+func (r *PGRepository) TeamName(teamID int) (string, error) {
+	var name sql.NullString
+	err := r.db.QueryRow(`SELECT name FROM bot.team WHERE id = $1`, teamID).Scan(&name)
+	if err != nil {
+		return "", fmt.Errorf("execute query: %w", err)
+	}
+
+	return name.String, nil
+}
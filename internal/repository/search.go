@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"tgbot/internal/model"
+)
+
+const (
+	searchConfig   = `'russian'`
+	headlineOption = `'StartSel=«, StopSel=», MaxWords=35, MinWords=15'`
+)
+
+// SearchTasks ranks the tasks visible to userID by full-text match of their
+// description, keeping those with every one of labels. Queries without
+// searchable words, e.g. only stop words, fall back to matching every token
+// as a substring.
+func (r *PGRepository) SearchTasks(userID int64, query string, labels []string) ([]*model.SearchResult, error) {
+	var empty bool
+	err := r.db.QueryRow(`SELECT numnode(websearch_to_tsquery(`+searchConfig+`, $1)) = 0`, query).Scan(&empty)
+	if err != nil {
+		return nil, err
+	}
+
+	if empty {
+		return r.searchTokens(userID, query, labels)
+	}
+
+	rows, err := r.db.Query(`SELECT `+taskColumns+`,
+		ts_headline(`+searchConfig+`, coalesce(description, ''), q, `+headlineOption+`),
+		ts_rank(search, q) AS rank
+		FROM bot.task, websearch_to_tsquery(`+searchConfig+`, $3) q
+		WHERE `+visibleTask+` AND status <> $2 AND search @@ q AND `+hasLabels("$4")+`
+		ORDER BY rank DESC, id DESC`, userID, model.TaskDraft, query, pq.Array(labels))
+	if err != nil {
+		return nil, err
+	}
+
+	return SearchRows(rows)
+}
+
+func (r *PGRepository) searchTokens(userID int64, query string, labels []string) ([]*model.SearchResult, error) {
+	patterns := []string{}
+	for _, token := range strings.Fields(query) {
+		patterns = append(patterns, "%"+escapeLike(token)+"%")
+	}
+
+	if len(patterns) == 0 && len(labels) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(`SELECT `+taskColumns+`, coalesce(description, ''), 0
+		FROM bot.task
+		WHERE `+visibleTask+` AND status <> $2 AND description ILIKE ALL ($3) AND `+hasLabels("$4")+`
+		ORDER BY id DESC`, userID, model.TaskDraft, pq.Array(patterns), pq.Array(labels))
+	if err != nil {
+		return nil, err
+	}
+
+	return SearchRows(rows)
+}
+
+func SearchRows(rows *sql.Rows) ([]*model.SearchResult, error) {
+	defer rows.Close()
+
+	var results []*model.SearchResult
+	for rows.Next() {
+		result := &model.SearchResult{}
+		task, err := scanTask(rows, &result.Headline, &result.Rank)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Task = task
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+// CreateSeries starts a recurring series with the task as its first instance.
+func (r *PGRepository) CreateSeries(taskID int, creatorID int64, rule string) (int, error) {
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	var seriesID int
+	err = tx.QueryRowContext(ctx, `INSERT INTO bot.task_series (creator_id, rule) VALUES ($1, $2) RETURNING id`, creatorID, rule).Scan(&seriesID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE bot.task SET series_id = $1 WHERE id = $2`, seriesID, taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return seriesID, tx.Commit()
+}
+
+func (r *PGRepository) GetSeries(seriesID int) (*model.Series, error) {
+	series := &model.Series{}
+	err := r.db.QueryRow(`SELECT s.id, COALESCE(s.creator_id, 0), s.rule, s.status, COALESCE(u.time_zone, '')
+		FROM bot.task_series s LEFT JOIN bot.user u ON u.id = s.creator_id
+		WHERE s.id = $1`, seriesID).Scan(
+		&series.ID,
+		&series.CreatorID,
+		&series.Rule,
+		&series.Status,
+		&series.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (r *PGRepository) UpdateSeriesStatus(seriesID int, status string) error {
+	_, err := r.exec(`UPDATE bot.task_series SET status = $1 WHERE id = $2`, status, seriesID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// latestInstance matches the tasks of the outer query that no later instance
+// of their series follows, also after the next instance was purged from the
+// trash and next_id was cleared.
+const latestInstance = `NOT EXISTS (SELECT 1 FROM bot.task later WHERE later.series_id = task.series_id AND later.id > task.id)`
+
+// DueSeriesTasks returns the latest instances of active series that are done
+// or past their deadline and have no next instance yet. A deleted instance
+// still advances its series, so deleting one occurrence skips it.
+func (r *PGRepository) DueSeriesTasks() ([]*model.Tasks, error) {
+	rows, err := r.db.Query(`SELECT `+taskColumns+` FROM bot.task
+		WHERE next_id IS NULL AND `+latestInstance+` AND status <> $1 AND (status = $2 OR deadline <= now())
+		AND series_id IN (SELECT id FROM bot.task_series WHERE status = $3)`,
+		model.TaskDraft, model.TaskDone, model.SeriesActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return TaskRows(rows)
+}
+
+// ErrAlreadySpawned is returned when the next instance of a task was created
+// concurrently.
+var ErrAlreadySpawned = errors.New("next instance already exists")
+
+// SpawnSeriesTask copies the task as the next open instance of its series
+// with the given deadline and links it from the previous instance.
+func (r *PGRepository) SpawnSeriesTask(prev *model.Tasks, deadline time.Time) (int, error) {
+	ctx := context.Background()
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	var taskID int
+	err = tx.QueryRowContext(ctx, `INSERT INTO bot.task (user_id, creator_id, team_id, series_id, status, complexity, priority, deadline, description)
+		SELECT user_id, creator_id, team_id, series_id, $1, complexity, priority, $2, description FROM bot.task WHERE id = $3
+		RETURNING id`, model.TaskOpen, deadline, prev.ID).Scan(&taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE bot.task SET next_id = $1 WHERE id = $2 AND next_id IS NULL`, taskID, prev.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrAlreadySpawned
+	}
+
+	return taskID, tx.Commit()
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"tgbot/internal/model"
+)
+
+// ErrTaskDeleted is returned when the task is already in the trash.
+var ErrTaskDeleted = errors.New("task already deleted")
+
+// RestoreTask takes the task back from the trash if it was deleted within the
+// window and reports whether it did.
+func (r *PGRepository) RestoreTask(taskID int, window time.Duration) (bool, error) {
+	res, err := r.exec(`UPDATE bot.task SET deleted_at = NULL WHERE id = $1 AND deleted_at > now() - $2 * interval '1 second'`,
+		taskID, window.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("execute: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// PurgeDeletedTasks removes the tasks deleted longer than retention ago for
+// good and returns how many there were. The latest instance of a series that
+// has not ended is kept, the next instance is spawned from it.
+func (r *PGRepository) PurgeDeletedTasks(retention time.Duration) (int64, error) {
+	res, err := r.exec(`DELETE FROM bot.task WHERE deleted_at < now() - $1 * interval '1 second'
+		AND NOT (series_id IS NOT NULL AND `+latestInstance+`
+			AND series_id IN (SELECT id FROM bot.task_series WHERE status <> $2))`, retention.Seconds(), model.SeriesEnded)
+	if err != nil {
+		return 0, fmt.Errorf("execute: %w", err)
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// Scheduler runs background jobs of the bot at fixed intervals.
+type Scheduler struct {
+	logger *zap.Logger
+	jobs   []job
+}
+
+func NewScheduler(logger *zap.Logger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// Every registers run to be called every interval once Start is called.
+func (s *Scheduler) Every(name string, interval time.Duration, run func() error) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs every registered job in its own goroutine.
+func (s *Scheduler) Start() {
+	for _, j := range s.jobs {
+		go s.loop(j)
+	}
+}
+
+func (s *Scheduler) loop(j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := j.run()
+		if err != nil {
+			s.logger.Error("scheduled job failed", zap.String("job", j.name), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"tgbot/internal/model"
+)
+
+// admin lets through the requests bearing the admin token.
+func (s *Server) admin(token string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// Audit returns audit entries as JSON, newest first. It accepts the query
+// parameters team_id, task_id, actor_id, target, since and until (RFC 3339),
+// before_id to page back and limit.
+func (s *Server) Audit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := auditQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := s.repo.AuditLog(q)
+	if err != nil {
+		s.log.Error("get audit log", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if entries == nil {
+		entries = []*model.AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(map[string]any{"entries": entries})
+	if err != nil {
+		s.log.Error("write audit log", zap.Error(err))
+	}
+}
+
+func auditQuery(r *http.Request) (*model.AuditQuery, error) {
+	values := r.URL.Query()
+	q := &model.AuditQuery{Target: values.Get("target")}
+
+	var err error
+	if q.TeamID, err = intParam(values.Get("team_id")); err != nil {
+		return nil, errors.New("invalid team_id")
+	}
+	if q.TaskID, err = intParam(values.Get("task_id")); err != nil {
+		return nil, errors.New("invalid task_id")
+	}
+	if q.Limit, err = intParam(values.Get("limit")); err != nil {
+		return nil, errors.New("invalid limit")
+	}
+	if q.ActorID, err = int64Param(values.Get("actor_id")); err != nil {
+		return nil, errors.New("invalid actor_id")
+	}
+	if q.BeforeID, err = int64Param(values.Get("before_id")); err != nil {
+		return nil, errors.New("invalid before_id")
+	}
+	if q.Since, err = timeParam(values.Get("since")); err != nil {
+		return nil, errors.New("invalid since")
+	}
+	if q.Until, err = timeParam(values.Get("until")); err != nil {
+		return nil, errors.New("invalid until")
+	}
+
+	return q, nil
+}
+
+func intParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(v)
+}
+
+func int64Param(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(v, 10, 64)
+}
+
+func timeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"tgbot/internal/pkg/ical"
+)
+
+// Calendar serves the live iCalendar feed of the open tasks of the user the
+// secret in the path belongs to.
+func (s *Server) Calendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, ical.FeedPath), ".ics")
+	if token == "" || strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := s.repo.CalendarUser(token)
+	if err != nil {
+		s.log.Error("get calendar user", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	tasks, err := s.repo.CalendarTasks(user.ID)
+	if err != nil {
+		s.log.Error("get calendar tasks", zap.Int64("user", user.ID), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, err = w.Write(ical.Calendar(user.Login, tasks, time.Now()))
+	if err != nil {
+		s.log.Error("write calendar", zap.Error(err))
+	}
+}
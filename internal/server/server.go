@@ -0,0 +1,50 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"tgbot/internal/pkg/ical"
+	"tgbot/internal/repository"
+)
+
+// Server serves the HTTP endpoints of the bot.
+type Server struct {
+	log  *zap.Logger
+	repo *repository.PGRepository
+	srv  *http.Server
+}
+
+func NewServer(log *zap.Logger, repo *repository.PGRepository, addr, adminToken string) *Server {
+	s := &Server{
+		log:  log,
+		repo: repo,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ical.FeedPath, s.Calendar)
+	if adminToken != "" {
+		mux.Handle("/api/audit", s.admin(adminToken, s.Audit))
+	}
+
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+// Start serves requests in its own goroutine.
+func (s *Server) Start() {
+	go func() {
+		err := s.srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("http server stopped", zap.Error(err))
+		}
+	}()
+}
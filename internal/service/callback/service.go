@@ -1,37 +1,48 @@
 package callback
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/go-redis/redis"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 
+	"tgbot/config"
 	"tgbot/internal/model"
+	"tgbot/internal/pkg/recurrence"
 	"tgbot/internal/pkg/utils"
+	rdb "tgbot/internal/redis"
 	"tgbot/internal/repository"
+	"tgbot/internal/service/policy"
 )
 
 type Service struct {
-	log   *zap.Logger
-	texts map[string]string
-	bot   *tgbotapi.BotAPI
-	rdb   *redis.Client
-	repo  *repository.PGRepository
+	log    *zap.Logger
+	texts  map[string]string
+	bot    *tgbotapi.BotAPI
+	rdb    *redis.Client
+	repo   *repository.PGRepository
+	policy *policy.Service
 }
 
-func NewCallbackService(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Service {
+func NewCallbackService(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string, policy *policy.Service) *Service {
 	return &Service{
-		log:   log,
-		rdb:   rdb,
-		repo:  repo,
-		bot:   bot,
-		texts: texts,
+		log:    log,
+		rdb:    rdb,
+		repo:   repo,
+		bot:    bot,
+		texts:  texts,
+		policy: policy,
 	}
 }
 
 func (c *Service) Yes(s *model.Situation) error {
-	err := c.repo.DeleteUserFromTeam(s.User.ID)
+	err := c.repo.As(s.User.ID).DeleteUserFromTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
@@ -43,6 +54,1311 @@ func (c *Service) No(s *model.Situation) error {
 	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "choose"))
 }
 
+var postponeHours = []struct {
+	key   string
+	hours int
+}{
+	{"postpone_hour", 1},
+	{"postpone_day", 24},
+	{"postpone_week", 7 * 24},
+}
+
+// Card restores the task card, e.g. after leaving a card sub-menu.
+func (c *Service) Card(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	return c.EditCard(s, task)
+}
+
+// CardDone finishes a task unless it still waits for other tasks, and lets the
+// assignees of the tasks it was the last blocker of know they can start.
+func (c *Service) CardDone(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	if len(task.BlockedBy) > 0 {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "task_blocked", task.ID, utils.TaskIDs(task.BlockedBy)))
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskStatus(task.ID, model.TaskDone)
+	if err != nil {
+		return err
+	}
+
+	task.Status = model.TaskDone
+
+	err = c.EditCard(s, task)
+	if err != nil {
+		return err
+	}
+
+	unblocked, err := c.repo.UnblockedBy(task.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, next := range unblocked {
+		userID := next.UserID
+		if userID == 0 {
+			userID = next.CreatorID
+		}
+
+		msg := tgbotapi.NewMessage(userID, utils.GetFormatText(c.texts, "task_unblocked", next.ID, task.ID))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "open_task", next.ID), utils.CallbackData("/card_open", next.ID))))
+
+		_, err = c.bot.Send(msg)
+		if err != nil {
+			c.log.Error("notify task unblocked", zap.Int("task", next.ID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (c *Service) CardEdit(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "choose_edit_field", task.ID), utils.EditFields(c.texts, task.ID))
+}
+
+func (c *Service) CardEditField(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	if len(s.Args) < 2 {
+		return fmt.Errorf("edit field not found")
+	}
+
+	var current string
+	switch field := s.Args[1]; field {
+	case model.FieldComplexity:
+		current = strconv.Itoa(task.Complexity)
+	case model.FieldDeadline:
+		current = utils.FormatDeadline(c.texts, task.Deadline, s.User.Location())
+	case model.FieldDescription:
+		current = task.Description
+	case model.FieldPriority:
+		markUp := tgbotapi.NewInlineKeyboardMarkup(
+			utils.PriorityRow(c.texts, func(priority string) string {
+				return utils.CallbackData("/card_priority", task.ID, priority)
+			}),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "back"), utils.CallbackData("/card", task.ID))))
+
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "choose_priority"), markUp)
+	default:
+		return fmt.Errorf("unknown edit field %q", field)
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/card_edited")
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, task.ID)
+	rdb.SetEditField(c.log, c.rdb, s.User.ID, s.Args[1])
+	rdb.SetCardMessageID(c.log, c.rdb, s.User.ID, s.CallbackQuery.Message.MessageID)
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "send_new_"+s.Args[1], current), utils.BackToCard(c.texts, task.ID))
+}
+
+// CardPriority saves the priority picked on the card and notifies the other party.
+func (c *Service) CardPriority(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	if len(s.Args) < 2 || !utils.ValidPriority(s.Args[1]) {
+		return fmt.Errorf("invalid priority %v", s.Args)
+	}
+
+	change := &model.TaskChange{
+		TaskID: task.ID,
+		UserID: s.User.ID,
+		Field:  model.FieldPriority,
+		Before: task.Priority,
+		After:  s.Args[1],
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskPriority(task.ID, change.After)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).AddTaskChange(change)
+	if err != nil {
+		return err
+	}
+
+	task.Priority = change.After
+	c.notifyChange(s.User.ID, task, change)
+
+	return c.EditCard(s, task)
+}
+
+// Priority saves the priority of the task being created and asks for its deadline.
+func (c *Service) Priority(s *model.Situation) error {
+	if rdb.GetPath(c.log, c.rdb, s.User.ID) != "priority" {
+		return nil
+	}
+
+	if len(s.Args) < 1 || !utils.ValidPriority(s.Args[0]) {
+		return fmt.Errorf("invalid priority %v", s.Args)
+	}
+
+	taskID, err := strconv.Atoi(rdb.GetTaskID(c.log, c.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskPriority(taskID, s.Args[0])
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/description")
+
+	err = c.EditMsg(s, utils.GetFormatText(c.texts, "priority_saved", utils.PriorityName(c.texts, s.Args[0])), tgbotapi.InlineKeyboardMarkup{})
+	if err != nil {
+		return err
+	}
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "send_deadline"))
+}
+
+func (c *Service) CardDelete(s *model.Situation) error {
+	task, err := c.manageTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	markUp := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "yes"), utils.CallbackData("/card_delete_yes", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "no"), utils.CallbackData("/card", task.ID))))
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "delete_task_sure", task.ID), markUp)
+}
+
+// CardDeleteYes moves the task to the trash and offers to undo the deletion.
+func (c *Service) CardDeleteYes(s *model.Situation) error {
+	task, err := c.manageTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).DeleteTask(task.ID)
+	if errors.Is(err, repository.ErrTaskDeleted) {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "task_already_deleted", task.ID), tgbotapi.InlineKeyboardMarkup{})
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "task_deleted_undo", task.ID, utils.FormatDuration(c.texts, config.C.UndoWindow)), utils.UndoDelete(c.texts, task.ID))
+}
+
+// TaskUndo restores a deleted task while the undo window lasts.
+func (c *Service) TaskUndo(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	ok, err := c.allow(s.User.ID, policy.ManageTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	restored, err := c.repo.As(s.User.ID).RestoreTask(taskID, config.C.UndoWindow)
+	if err != nil {
+		return err
+	}
+
+	if !restored {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "undo_expired", taskID), tgbotapi.InlineKeyboardMarkup{})
+	}
+
+	task, err := c.repo.GetTaskInfo(taskID)
+	if err != nil {
+		return fmt.Errorf("get task %d: %w", taskID, err)
+	}
+
+	return c.EditCard(s, task)
+}
+
+func (c *Service) CardReassign(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	teamID := task.TeamID
+	if teamID == 0 {
+		teamID, err = c.repo.CheckTeam(s.User.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if teamID == 0 {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "team_need_create"), utils.BackToCard(c.texts, task.ID))
+	}
+
+	team, err := c.repo.YourTeam(teamID)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, user := range team.Users {
+		if user.ID == task.UserID {
+			continue
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(user.Login, utils.CallbackData("/card_reassign_to", task.ID, user.ID))))
+	}
+	rows = append(rows, utils.BackToCard(c.texts, task.ID).InlineKeyboard...)
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "choose_new_assignee", task.ID), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (c *Service) CardReassignTo(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	userID, err := utils.Int64Arg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	ok, err := c.allow(s.User.ID, policy.AssignTask, policy.Resource{UserID: userID})
+	if err != nil || !ok {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskUser(task.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	task.UserID = userID
+	err = c.SendMsgToUser(userID, utils.GetFormatText(c.texts, "task_info_to_user", task.Complexity, utils.FormatDeadline(c.texts, task.Deadline, c.location(userID)), task.Description))
+	if err != nil {
+		return err
+	}
+
+	return c.EditCard(s, task)
+}
+
+func (c *Service) CardPostpone(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, p := range postponeHours {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, p.key), utils.CallbackData("/card_postpone_by", task.ID, p.hours)))
+	}
+
+	markUp := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(buttons...))
+	markUp.InlineKeyboard = append(markUp.InlineKeyboard, utils.BackToCard(c.texts, task.ID).InlineKeyboard...)
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "postpone_task", task.ID), markUp)
+}
+
+func (c *Service) CardPostponeBy(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	hours, err := utils.IntArg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).PostponeTask(task.ID, hours)
+	if err != nil {
+		return err
+	}
+
+	task, err = c.repo.GetTaskInfo(task.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.EditCard(s, task)
+}
+
+// CardOpen sends the card of a task picked from a task list.
+func (c *Service) CardOpen(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	text, markUp := utils.TaskCard(c.texts, s.User.Location(), task)
+	msg := tgbotapi.NewMessage(s.User.ID, text)
+	msg.ReplyMarkup = markUp
+
+	_, err = c.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("send card to user: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBacklog creates the task being set up without an assignee, into the
+// backlog of the creator's team, and continues with its complexity.
+func (c *Service) CreateBacklog(s *model.Situation) error {
+	if rdb.GetPath(c.log, c.rdb, s.User.ID) != "/complexity" {
+		return nil
+	}
+
+	teamId, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if teamId == 0 {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "team_need_create"))
+	}
+
+	taskID, err := c.repo.As(s.User.ID).AddUserToTaskBar(0, s.User.ID, teamId)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/deadline")
+	rdb.SetTaskUserID(c.log, c.rdb, s.User.ID, 0)
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, taskID)
+
+	err = c.EditMsg(s, utils.GetFormatText(c.texts, "backlog_task_chosen"), tgbotapi.InlineKeyboardMarkup{})
+	if err != nil {
+		return err
+	}
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "complexity"))
+}
+
+// AssignAccept creates the task being set up for the assignee suggested by the
+// team policy and continues with its complexity.
+func (c *Service) AssignAccept(s *model.Situation) error {
+	if rdb.GetPath(c.log, c.rdb, s.User.ID) != "/complexity" {
+		return nil
+	}
+
+	userID, err := utils.Int64Arg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	ok, err := c.allow(s.User.ID, policy.AssignTask, policy.Resource{UserID: userID})
+	if err != nil || !ok {
+		return err
+	}
+
+	teamId, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := c.repo.As(s.User.ID).AddUserToTaskBar(userID, s.User.ID, teamId)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).UpdateLastAssignee(teamId, userID)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/deadline")
+	rdb.SetTaskUserID(c.log, c.rdb, s.User.ID, userID)
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, taskID)
+
+	err = c.EditMsg(s, s.CallbackQuery.Message.Text, tgbotapi.InlineKeyboardMarkup{})
+	if err != nil {
+		return err
+	}
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "complexity"))
+}
+
+// AssignPolicy switches the assignment policy of the team of the admin.
+func (c *Service) AssignPolicy(s *model.Situation) error {
+	if len(s.Args) < 1 || !slices.Contains(model.AssignPolicies, s.Args[0]) {
+		return fmt.Errorf("invalid assignment policy %v", s.Args)
+	}
+
+	teamId, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTeamPolicy(teamId, s.Args[0])
+	if err != nil {
+		return err
+	}
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "assign_policy_saved", utils.GetFormatText(c.texts, "assign_"+s.Args[0])), utils.PolicyToggles(c.texts, s.Args[0]))
+}
+
+// CardClaim assigns a backlog task to the member who pressed the claim button
+// and lets its creator know.
+func (c *Service) CardClaim(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	claimed, err := c.repo.As(s.User.ID).ClaimTask(task.ID, s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		err = c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "task_already_claimed", task.ID))
+		if err != nil {
+			return err
+		}
+	}
+
+	task, err = c.repo.GetTaskInfo(task.ID)
+	if err != nil {
+		return err
+	}
+
+	err = c.EditCard(s, task)
+	if err != nil || !claimed || task.CreatorID == s.User.ID {
+		return err
+	}
+
+	login, err := c.repo.CheckUserRegister(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(task.CreatorID, utils.GetFormatText(c.texts, "task_claimed", task.ID, login))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "open_task", task.ID), utils.CallbackData("/card_open", task.ID))))
+
+	_, err = c.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("notify task claimed: %w", err)
+	}
+
+	return nil
+}
+
+// CardChecklist shows a page of the checklist of a task in place of its card.
+func (c *Service) CardChecklist(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	page := 0
+	if len(s.Args) > 1 {
+		page, err = utils.IntArg(s.Args, 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.editChecklist(s, task.ID, page)
+}
+
+// CardChecklistAdd asks for new checklist items, one per line.
+func (c *Service) CardChecklistAdd(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/checklist_added")
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, task.ID)
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "send_checklist", task.ID))
+}
+
+// CardItem toggles a checklist item. Checking off the last open item asks the
+// assignee whether to close the task.
+func (c *Service) CardItem(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	itemID, err := utils.IntArg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	page, err := utils.IntArg(s.Args, 2)
+	if err != nil {
+		return err
+	}
+
+	done, err := c.repo.As(s.User.ID).ToggleTaskItem(task.ID, itemID)
+	if err != nil {
+		return err
+	}
+
+	err = c.editChecklist(s, task.ID, page)
+	if err != nil {
+		return err
+	}
+
+	if !done || task.Status != model.TaskOpen {
+		return nil
+	}
+
+	task, err = c.repo.GetTaskInfo(task.ID)
+	if err != nil {
+		return err
+	}
+
+	if task.ItemsDone < task.Items {
+		return nil
+	}
+
+	assigneeID := task.UserID
+	if assigneeID == 0 {
+		assigneeID = s.User.ID
+	}
+
+	msg := tgbotapi.NewMessage(assigneeID, utils.GetFormatText(c.texts, "checklist_completed", task.ID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "card_done"), utils.CallbackData("/card_done", task.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "open_task", task.ID), utils.CallbackData("/card_open", task.ID))))
+
+	_, err = c.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("ask to close task: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Service) editChecklist(s *model.Situation, taskID, page int) error {
+	items, err := c.repo.GetTaskItems(taskID)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.ChecklistPage(c.texts, taskID, items, page)
+	return c.EditMsg(s, text, markUp)
+}
+
+// CardLabels shows the labels of the task's team as toggles in place of its card.
+func (c *Service) CardLabels(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	return c.editLabels(s, task)
+}
+
+// CardLabel puts a team label on the task or takes it off.
+func (c *Service) CardLabel(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	labelID, err := utils.IntArg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.As(s.User.ID).ToggleTaskLabel(task.ID, labelID)
+	if err != nil {
+		return err
+	}
+
+	task, err = c.repo.GetTaskInfo(task.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.editLabels(s, task)
+}
+
+func (c *Service) editLabels(s *model.Situation, task *model.Tasks) error {
+	labels, err := c.repo.TeamLabels(task.TeamID)
+	if err != nil {
+		return err
+	}
+
+	if labels == nil {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "labels_empty"), utils.BackToCard(c.texts, task.ID))
+	}
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "choose_labels", task.ID), utils.LabelToggles(c.texts, task, labels))
+}
+
+// CardComments shows a page of the comment thread of a task in place of its card.
+func (c *Service) CardComments(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	page := 0
+	if len(s.Args) > 1 {
+		page, err = utils.IntArg(s.Args, 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.editComments(s, task.ID, page)
+}
+
+// CardComment asks for the text of a new comment to the task.
+func (c *Service) CardComment(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/comment_added")
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, task.ID)
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "send_comment", task.ID))
+}
+
+// CardWatch subscribes the user to the comments of the task or unsubscribes them.
+func (c *Service) CardWatch(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	watching, err := c.repo.IsWatcher(task.ID, s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if watching {
+		err = c.repo.As(s.User.ID).RemoveWatcher(task.ID, s.User.ID)
+	} else {
+		err = c.repo.As(s.User.ID).AddWatcher(task.ID, s.User.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.editComments(s, task.ID, 0)
+}
+
+func (c *Service) editComments(s *model.Situation, taskID, page int) error {
+	comments, err := c.repo.GetComments(taskID)
+	if err != nil {
+		return err
+	}
+
+	watching, err := c.repo.IsWatcher(taskID, s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.CommentsPage(c.texts, s.User.Location(), taskID, comments, watching, page, config.C.PageSize)
+	return c.EditMsg(s, text, markUp)
+}
+
+// CardAttachments lists the files attached to a task in place of its card.
+func (c *Service) CardAttachments(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	page := 0
+	if len(s.Args) > 1 {
+		page, err = utils.IntArg(s.Args, 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	attachments, err := c.repo.GetAttachments(task.ID)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.AttachmentsPage(c.texts, task.ID, attachments, page, config.C.PageSize)
+	return c.EditMsg(s, text, markUp)
+}
+
+// CardAttach asks for files to attach to the task.
+func (c *Service) CardAttach(s *model.Situation) error {
+	task, err := c.viewTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/attachment_added")
+	rdb.SetTaskID(c.log, c.rdb, s.User.ID, task.ID)
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "send_attachment", task.ID))
+}
+
+// AttachmentSend re-sends an attached file with sendDocument, sendPhoto or sendVoice.
+func (c *Service) AttachmentSend(s *model.Situation) error {
+	attachmentID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := c.repo.GetAttachment(attachmentID)
+	if err != nil {
+		return fmt.Errorf("get attachment %d: %w", attachmentID, err)
+	}
+
+	ok, err := c.allow(s.User.ID, policy.ViewTask, policy.Resource{TaskID: attachment.TaskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	_, err = c.bot.Send(utils.AttachmentFile(s.User.ID, attachment))
+	if err != nil {
+		return fmt.Errorf("send attachment: %w", err)
+	}
+
+	return nil
+}
+
+// Export sends the tasks of the chosen scope as a document in the chosen format.
+func (c *Service) Export(s *model.Situation) error {
+	if len(s.Args) < 2 {
+		return fmt.Errorf("export arguments not found")
+	}
+
+	teamID, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if s.Args[0] == model.ExportTeam && teamID == 0 {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "team_need_create"))
+	}
+
+	f, err := utils.ExportFilter(s.Args[0], s.User.ID, teamID)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := c.repo.FilterTasks(s.User.ID, f)
+	if err != nil {
+		return err
+	}
+
+	if len(tasks) == 0 {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "export_empty"))
+	}
+
+	doc, err := utils.ExportDocument(s.User.ID, s.Args[1], s.User.Location(), tasks)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.bot.Send(doc)
+	if err != nil {
+		return fmt.Errorf("send export: %w", err)
+	}
+
+	return nil
+}
+
+// ImportConfirm creates the tasks of the previewed import and notifies their
+// assignees.
+func (c *Service) ImportConfirm(s *model.Situation) error {
+	rows := rdb.TakeImportRows(c.log, c.rdb, s.User.ID)
+	if len(rows) == 0 {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "import_expired"), tgbotapi.InlineKeyboardMarkup{})
+	}
+
+	teamID, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	ids, err := c.repo.As(s.User.ID).ImportTasks(s.User.ID, teamID, rows)
+	if errors.Is(err, repository.ErrImportAssignee) {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "import_failed_assignee"), tgbotapi.InlineKeyboardMarkup{})
+	}
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "import_done")
+
+	err = c.EditMsg(s, utils.GetFormatText(c.texts, "import_done", len(ids), utils.TaskIDs(ids)), tgbotapi.InlineKeyboardMarkup{})
+	if err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		if row.AssigneeID == s.User.ID {
+			continue
+		}
+
+		text := utils.GetFormatText(c.texts, "task_info_to_user", row.Complexity, utils.FormatDeadline(c.texts, row.Deadline, c.location(row.AssigneeID)), row.Description)
+		msg := tgbotapi.NewMessage(row.AssigneeID, text)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "open_task", ids[i]), utils.CallbackData("/card", ids[i]))))
+
+		_, err = c.bot.Send(msg)
+		if err != nil {
+			c.log.Error("notify imported task assignee", zap.Int64("user", row.AssigneeID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// ImportCancel drops the previewed import.
+func (c *Service) ImportCancel(s *model.Situation) error {
+	rdb.TakeImportRows(c.log, c.rdb, s.User.ID)
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "import_canceled")
+
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "import_canceled"), tgbotapi.InlineKeyboardMarkup{})
+}
+
+// History shows the audit entries older than the given one in place.
+func (c *Service) History(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	beforeID, err := utils.Int64Arg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	teamID, err := c.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.repo.AuditLog(&model.AuditQuery{TeamID: teamID, TaskID: taskID, BeforeID: beforeID, Limit: utils.HistoryPageSize + 1})
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return c.EditMsg(s, utils.GetFormatText(c.texts, "history_empty"), tgbotapi.InlineKeyboardMarkup{})
+	}
+
+	more := len(entries) > utils.HistoryPageSize
+	text, markUp := utils.HistoryPage(c.texts, s.User.Location(), taskID, entries[:min(len(entries), utils.HistoryPageSize)], more)
+	return c.EditMsg(s, text, markUp)
+}
+
+// Page shows another page of a list in place.
+func (c *Service) Page(s *model.Situation) error {
+	if len(s.Args) < 2 {
+		return fmt.Errorf("page arguments not found")
+	}
+
+	page, err := utils.IntArg(s.Args, 1)
+	if err != nil {
+		return err
+	}
+
+	switch list := s.Args[0]; list {
+	case utils.ListTasks:
+		tasks, err := c.repo.GetTasksInfo(s.User.ID)
+		if err != nil {
+			return err
+		}
+
+		if tasks == nil {
+			return c.EditMsg(s, utils.GetFormatText(c.texts, "no_tasks_found"), tgbotapi.InlineKeyboardMarkup{})
+		}
+
+		text, markUp := utils.TasksPage(c.texts, s.User.Location(), utils.ListTasks, utils.GetFormatText(c.texts, "tasks_page"), tasks, page, config.C.PageSize)
+		return c.EditMsg(s, text, markUp)
+	case utils.ListFilteredTasks:
+		return c.filteredTasks(s, rdb.GetTaskFilter(c.log, c.rdb, s.User.ID), page)
+	case utils.ListSearch:
+		query := rdb.GetSearchQuery(c.log, c.rdb, s.User.ID)
+		words, labels := utils.SplitSearchQuery(query)
+		results, err := c.repo.SearchTasks(s.User.ID, words, labels)
+		if err != nil {
+			return err
+		}
+
+		text, markUp := utils.SearchPage(c.texts, s.User.Location(), query, results, page, config.C.PageSize)
+		return c.EditMsg(s, text, markUp)
+	case utils.ListBacklog:
+		teamId, err := c.repo.CheckTeam(s.User.ID)
+		if err != nil {
+			return err
+		}
+
+		tasks, err := c.repo.TeamBacklog(teamId)
+		if err != nil {
+			return err
+		}
+
+		if tasks == nil {
+			return c.EditMsg(s, utils.GetFormatText(c.texts, "backlog_empty"), tgbotapi.InlineKeyboardMarkup{})
+		}
+
+		text, markUp := utils.TasksPage(c.texts, s.User.Location(), utils.ListBacklog, utils.GetFormatText(c.texts, "backlog_page"), tasks, page, config.C.PageSize)
+		return c.EditMsg(s, text, markUp)
+	case utils.ListTeam, utils.ListDeleteUser, utils.ListCreateTask:
+		teamId, err := c.repo.CheckTeam(s.User.ID)
+		if err != nil {
+			return err
+		}
+
+		if teamId == 0 {
+			return c.EditMsg(s, utils.GetFormatText(c.texts, "team_need_create"), tgbotapi.InlineKeyboardMarkup{})
+		}
+
+		team, err := c.repo.YourTeam(teamId)
+		if err != nil {
+			return err
+		}
+
+		text, markUp := utils.TeamPage(c.texts, list, team, page, config.C.PageSize)
+		return c.EditMsg(s, text, markUp)
+	default:
+		return fmt.Errorf("unknown list %q", list)
+	}
+}
+
+// Filter toggles one task filter value and shows the first page of matching tasks.
+func (c *Service) Filter(s *model.Situation) error {
+	if len(s.Args) < 2 {
+		return fmt.Errorf("filter arguments not found")
+	}
+
+	f := rdb.GetTaskFilter(c.log, c.rdb, s.User.ID)
+	err := utils.ApplyTaskFilter(f, s.Args[0], s.Args[1], s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetTaskFilter(c.log, c.rdb, s.User.ID, f)
+
+	return c.filteredTasks(s, f, 0)
+}
+
+func (c *Service) filteredTasks(s *model.Situation, f *model.TaskFilter, page int) error {
+	tasks, err := c.repo.FilterTasks(s.User.ID, f)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.FilteredTasksPage(c.texts, s.User.Location(), f, s.User.ID, tasks, page, config.C.PageSize)
+	return c.EditMsg(s, text, markUp)
+}
+
+// DeadlineOK saves the confirmed deadline of the task being created and asks
+// for its description.
+func (c *Service) DeadlineOK(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	due, err := rdb.GetPendingDeadline(c.log, c.rdb, s.User.ID, taskID)
+	if err != nil {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "some_wrong"))
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskDeadline(taskID, due)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "/task_created")
+
+	err = c.EditMsg(s, utils.GetFormatText(c.texts, "deadline_saved", utils.FormatDeadline(c.texts, due, s.User.Location())), tgbotapi.InlineKeyboardMarkup{})
+	if err != nil {
+		return err
+	}
+
+	return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "send_description"))
+}
+
+func (c *Service) DeadlineRetry(s *model.Situation) error {
+	return c.EditMsg(s, utils.GetFormatText(c.texts, "send_deadline"), tgbotapi.InlineKeyboardMarkup{})
+}
+
+// CardDeadlineOK saves the confirmed deadline entered while editing a task card.
+func (c *Service) CardDeadlineOK(s *model.Situation) error {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil {
+		return err
+	}
+
+	due, err := rdb.GetPendingDeadline(c.log, c.rdb, s.User.ID, task.ID)
+	if err != nil {
+		return c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "some_wrong"))
+	}
+
+	err = c.repo.As(s.User.ID).UpdateTaskDeadline(task.ID, due)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(c.log, c.rdb, s.User.ID, "card_edited")
+
+	change := &model.TaskChange{
+		TaskID: task.ID,
+		UserID: s.User.ID,
+		Field:  model.FieldDeadline,
+		Before: task.Deadline.Format(time.RFC3339),
+		After:  due.Format(time.RFC3339),
+	}
+	task.Deadline = due
+
+	err = c.repo.As(s.User.ID).AddTaskChange(change)
+	if err != nil {
+		return err
+	}
+
+	c.notifyChange(s.User.ID, task, change)
+
+	return c.EditCard(s, task)
+}
+
+// notifyChange sends the before/after diff of a task to its creator or
+// assignee, whoever is not the actor.
+func (c *Service) notifyChange(actorID int64, task *model.Tasks, change *model.TaskChange) {
+	notifyID := task.UserID
+	if actorID == task.UserID {
+		notifyID = task.CreatorID
+	}
+
+	if notifyID == 0 || notifyID == actorID {
+		return
+	}
+
+	before, after := change.Before, change.After
+	switch change.Field {
+	case model.FieldDeadline:
+		loc := c.location(notifyID)
+		before, after = formatChangedDeadline(c.texts, before, loc), formatChangedDeadline(c.texts, after, loc)
+	case model.FieldPriority:
+		before, after = utils.PriorityName(c.texts, before), utils.PriorityName(c.texts, after)
+	}
+
+	field := utils.GetFormatText(c.texts, "field_"+change.Field)
+	err := c.SendMsgToUser(notifyID, utils.GetFormatText(c.texts, "task_changed", task.ID, field, before, after))
+	if err != nil {
+		c.log.Error("notify task change", zap.Error(err))
+	}
+}
+
+// CardSeries shows the recurrence of the task series with pause, resume and
+// end buttons for its creator.
+func (c *Service) CardSeries(s *model.Situation) error {
+	task, series, err := c.cardSeries(s)
+	if err != nil || series == nil {
+		return err
+	}
+
+	return c.editSeries(s, task, series)
+}
+
+func (c *Service) SeriesPause(s *model.Situation) error {
+	return c.setSeriesStatus(s, model.SeriesPaused)
+}
+
+func (c *Service) SeriesResume(s *model.Situation) error {
+	return c.setSeriesStatus(s, model.SeriesActive)
+}
+
+func (c *Service) SeriesEnd(s *model.Situation) error {
+	return c.setSeriesStatus(s, model.SeriesEnded)
+}
+
+func (c *Service) setSeriesStatus(s *model.Situation, status string) error {
+	task, series, err := c.cardSeries(s)
+	if err != nil || series == nil {
+		return err
+	}
+
+	ok, err := c.allow(s.User.ID, policy.ManageSeries, policy.Resource{TaskID: task.ID})
+	if err != nil || !ok {
+		return err
+	}
+
+	if series.Status == model.SeriesEnded {
+		return c.editSeries(s, task, series)
+	}
+
+	err = c.repo.As(s.User.ID).UpdateSeriesStatus(series.ID, status)
+	if err != nil {
+		return err
+	}
+
+	series.Status = status
+
+	return c.editSeries(s, task, series)
+}
+
+func (c *Service) cardSeries(s *model.Situation) (*model.Tasks, *model.Series, error) {
+	task, err := c.cardTask(s)
+	if err != nil || task == nil || task.SeriesID == 0 {
+		return nil, nil, err
+	}
+
+	series, err := c.repo.GetSeries(task.SeriesID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return task, series, nil
+}
+
+func (c *Service) editSeries(s *model.Situation, task *model.Tasks, series *model.Series) error {
+	description := series.Rule
+	rule, err := recurrence.Parse(series.Rule, task.Deadline.In(s.User.Location()))
+	if err == nil {
+		description = utils.DescribeRule(c.texts, rule)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if c.policy.Check(s.User.ID, policy.ManageSeries, policy.Resource{TaskID: task.ID}) == nil {
+		switch series.Status {
+		case model.SeriesActive:
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "series_pause"), utils.CallbackData("/series_pause", task.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "series_end"), utils.CallbackData("/series_end", task.ID))))
+		case model.SeriesPaused:
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "series_resume"), utils.CallbackData("/series_resume", task.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(c.texts, "series_end"), utils.CallbackData("/series_end", task.ID))))
+		}
+	}
+	rows = append(rows, utils.BackToCard(c.texts, task.ID).InlineKeyboard...)
+
+	text := utils.GetFormatText(c.texts, "series_info", task.ID, description, utils.GetFormatText(c.texts, "series_"+series.Status))
+	return c.EditMsg(s, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// formatChangedDeadline prints a deadline recorded in a task change in loc.
+func formatChangedDeadline(texts map[string]string, value string, loc *time.Location) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+
+	return utils.FormatDeadline(texts, t, loc)
+}
+
+// location returns the time zone of another user, e.g. the recipient of a notification.
+func (c *Service) location(userID int64) *time.Location {
+	tz, err := c.repo.GetUserTimeZone(userID)
+	if err != nil {
+		c.log.Error("get user time zone", zap.Error(err))
+	}
+
+	return (&model.User{TimeZone: tz}).Location()
+}
+
+// cardTask loads the task whose ID is the first callback argument. It returns
+// nil without error when the user is neither the assignee nor the creator.
+func (c *Service) cardTask(s *model.Situation) (*model.Tasks, error) {
+	return c.policyTask(s, policy.EditTask)
+}
+
+// manageTask loads the task whose ID is the first callback argument for
+// deletion. Besides the assignee and the creator it lets in the admins of the
+// task's team.
+func (c *Service) manageTask(s *model.Situation) (*model.Tasks, error) {
+	return c.policyTask(s, policy.ManageTask)
+}
+
+// viewTask loads the task whose ID is the first callback argument for reading
+// and commenting. Unlike cardTask it lets in the members of the task's team.
+func (c *Service) viewTask(s *model.Situation) (*model.Tasks, error) {
+	return c.policyTask(s, policy.ViewTask)
+}
+
+func (c *Service) policyTask(s *model.Situation, action policy.Action) (*model.Tasks, error) {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := c.allow(s.User.ID, action, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	task, err := c.repo.GetTaskInfo(taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Only tasks in the trash pass the policy without being found.
+		return nil, c.SendMsgToUser(s.User.ID, utils.GetFormatText(c.texts, "task_already_deleted", taskID))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get task %d: %w", taskID, err)
+	}
+
+	return task, nil
+}
+
+// allow checks the action on the resource with the policy. It returns false
+// after telling the user why the action is denied.
+func (c *Service) allow(userID int64, action policy.Action, res policy.Resource) (bool, error) {
+	err := c.policy.Check(userID, action, res)
+	var denied *policy.Denied
+	if errors.As(err, &denied) {
+		return false, c.SendMsgToUser(userID, utils.GetFormatText(c.texts, denied.Key))
+	}
+
+	return err == nil, err
+}
+
+func (c *Service) EditCard(s *model.Situation, task *model.Tasks) error {
+	text, markUp := utils.TaskCard(c.texts, s.User.Location(), task)
+	return c.EditMsg(s, text, markUp)
+}
+
+// EditMsg replaces the text and inline keyboard of the message the callback came
+// from. An empty markUp removes the keyboard.
+func (c *Service) EditMsg(s *model.Situation, text string, markUp tgbotapi.InlineKeyboardMarkup) error {
+	msg := tgbotapi.NewEditMessageText(s.User.ID, s.CallbackQuery.Message.MessageID, text)
+	if len(markUp.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = &markUp
+	}
+
+	_, err := c.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("edit msg: %w", err)
+	}
+
+	return nil
+}
+
 func (c *Service) SendMsgToUser(userID int64, text string) error {
 	msg := &tgbotapi.MessageConfig{
 		BaseChat: tgbotapi.BaseChat{
@@ -0,0 +1,109 @@
+package digest
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"tgbot/internal/model"
+	"tgbot/internal/pkg/utils"
+	"tgbot/internal/repository"
+)
+
+// maxTasks is the number of tasks a digest sends with quick actions.
+const maxTasks = 10
+
+type Service struct {
+	log   *zap.Logger
+	texts map[string]string
+	bot   *tgbotapi.BotAPI
+	repo  *repository.PGRepository
+}
+
+func NewDigestService(log *zap.Logger, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Service {
+	return &Service{
+		log:   log,
+		repo:  repo,
+		bot:   bot,
+		texts: texts,
+	}
+}
+
+// SendDue sends the morning digest to every user whose digest time has come.
+func (d *Service) SendDue() error {
+	users, err := d.repo.DueDigests()
+	if err != nil {
+		return fmt.Errorf("get due digests: %w", err)
+	}
+
+	for _, user := range users {
+		err = d.send(user)
+		if err != nil {
+			d.log.Error("send digest", zap.Int64("user", user.UserID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// send marks the digest of the day as sent before sending it, so a user with
+// nothing due is not checked again until tomorrow. The mark is taken back when
+// the summary could not be sent.
+func (d *Service) send(user *model.DigestUser) error {
+	marked, err := d.repo.MarkDigestSent(user.UserID)
+	if err != nil || !marked {
+		return err
+	}
+
+	items, err := d.summary(user)
+	if err != nil {
+		restoreErr := d.repo.RestoreDigestSent(user.UserID, user.LastSent)
+		if restoreErr != nil {
+			d.log.Error("restore digest mark", zap.Int64("user", user.UserID), zap.Error(restoreErr))
+		}
+
+		return err
+	}
+
+	for _, item := range items {
+		msg := tgbotapi.NewMessage(user.UserID, item.Text)
+		msg.ReplyMarkup = item.MarkUp
+
+		_, err = d.bot.Send(msg)
+		if err != nil {
+			return fmt.Errorf("send digest task: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// summary sends the summary of the digest and returns the tasks to send with
+// quick actions.
+func (d *Service) summary(user *model.DigestUser) ([]utils.DigestItem, error) {
+	since := user.LastSent
+	if since.IsZero() {
+		since = time.Now().Add(-24 * time.Hour)
+	}
+
+	digest, err := d.repo.Digest(user.UserID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest.Empty() {
+		return nil, nil
+	}
+
+	loc := (&model.User{TimeZone: user.TimeZone}).Location()
+	summary, items := utils.DigestMessages(d.texts, loc, digest, maxTasks)
+
+	_, err = d.bot.Send(tgbotapi.NewMessage(user.UserID, summary))
+	if err != nil {
+		return nil, fmt.Errorf("send digest summary: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,116 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"tgbot/internal/model"
+	"tgbot/internal/repository"
+)
+
+const descriptionPrefix = "menu_"
+
+type Service struct {
+	log      *zap.Logger
+	bot      *tgbotapi.BotAPI
+	repo     *repository.PGRepository
+	texts    map[string]string
+	langs    map[string]map[string]string
+	commands []*model.MenuCommand
+}
+
+func NewMenuService(log *zap.Logger, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string, langs map[string]map[string]string) *Service {
+	return &Service{
+		log:   log,
+		bot:   bot,
+		repo:  repo,
+		texts: texts,
+		langs: langs,
+	}
+}
+
+// Register adds a handler command to the published menu. It is called from
+// MessageHandlers.Init so the menu always matches the registered handlers.
+func (m *Service) Register(command string, scopes model.MenuScope) {
+	m.commands = append(m.commands, &model.MenuCommand{Command: command, Scopes: scopes})
+}
+
+// Publish sends the private, group and team admin command sets to Telegram
+// for the default texts and for every configured language.
+func (m *Service) Publish() error {
+	scopes := []struct {
+		scope tgbotapi.BotCommandScope
+		menu  model.MenuScope
+	}{
+		{tgbotapi.NewBotCommandScopeDefault(), model.MenuPrivate},
+		{tgbotapi.NewBotCommandScopeAllPrivateChats(), model.MenuPrivate},
+		{tgbotapi.NewBotCommandScopeAllGroupChats(), model.MenuGroup},
+	}
+
+	for _, sc := range scopes {
+		err := m.publish(sc.scope, sc.menu)
+		if err != nil {
+			return err
+		}
+	}
+
+	admins, err := m.repo.TeamAdmins()
+	if err != nil {
+		return fmt.Errorf("get team admins: %w", err)
+	}
+
+	for _, id := range admins {
+		err = m.PublishAdmin(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PublishAdmin sends the team admin command set to the private chat of userID.
+func (m *Service) PublishAdmin(userID int64) error {
+	return m.publish(tgbotapi.NewBotCommandScopeChat(userID), model.MenuPrivate|model.MenuTeamAdmin)
+}
+
+func (m *Service) publish(scope tgbotapi.BotCommandScope, menu model.MenuScope) error {
+	_, err := m.bot.Request(tgbotapi.NewSetMyCommandsWithScope(scope, m.Commands(m.texts, menu)...))
+	if err != nil {
+		return fmt.Errorf("set %s commands: %w", scope.Type, err)
+	}
+
+	for lang, texts := range m.langs {
+		_, err = m.bot.Request(tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, lang, m.Commands(texts, menu)...))
+		if err != nil {
+			return fmt.Errorf("set %s commands for %s: %w", scope.Type, lang, err)
+		}
+	}
+
+	return nil
+}
+
+// Commands returns the registered commands visible in any of the menu scopes,
+// described with the "menu_<command>" keys of texts.
+func (m *Service) Commands(texts map[string]string, menu model.MenuScope) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, c := range m.commands {
+		if !c.In(menu) {
+			continue
+		}
+
+		name := strings.TrimPrefix(c.Command, "/")
+		description, ok := texts[descriptionPrefix+name]
+		if !ok {
+			m.log.Warn("menu description not found", zap.String("command", c.Command))
+			continue
+		}
+
+		commands = append(commands, tgbotapi.BotCommand{Command: name, Description: description})
+	}
+
+	return commands
+}
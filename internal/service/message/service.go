@@ -1,7 +1,12 @@
 package message
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -12,10 +17,16 @@ import (
 
 	"tgbot/config"
 	"tgbot/internal/model"
+	"tgbot/internal/pkg/assign"
 	"tgbot/internal/pkg/crypto"
+	"tgbot/internal/pkg/deadline"
+	"tgbot/internal/pkg/ical"
+	"tgbot/internal/pkg/recurrence"
 	"tgbot/internal/pkg/utils"
 	rdb "tgbot/internal/redis"
 	"tgbot/internal/repository"
+	"tgbot/internal/service/menu"
+	"tgbot/internal/service/policy"
 )
 
 type Service struct {
@@ -24,15 +35,19 @@ type Service struct {
 	bot    *tgbotapi.BotAPI
 	rdb    *redis.Client
 	repo   *repository.PGRepository
+	menu   *menu.Service
+	policy *policy.Service
 }
 
-func NewMessageService(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Service {
+func NewMessageService(log *zap.Logger, rdb *redis.Client, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string, menu *menu.Service, policy *policy.Service) *Service {
 	return &Service{
 		logger: log,
 		bot:    bot,
 		rdb:    rdb,
 		repo:   repo,
 		texts:  texts,
+		menu:   menu,
+		policy: policy,
 	}
 }
 
@@ -109,9 +124,18 @@ func (m *Service) Password(s *model.Situation) error {
 		Password:   password,
 		TgName:     s.Message.Chat.FirstName,
 		TgUsername: s.Message.Chat.UserName,
+		TimeZone:   model.DefaultTimeZone,
+	}
+	if s.Message.From != nil {
+		user.TimeZone = utils.GuessTimeZone(s.Message.From.LanguageCode)
+	}
+
+	err = m.repo.As(s.User.ID).AddNewUser(user)
+	if err != nil {
+		return err
 	}
 
-	err = m.repo.AddNewUser(user)
+	err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "timezone_guessed", user.TimeZone))
 	if err != nil {
 		return err
 	}
@@ -175,16 +199,33 @@ func (m *Service) CreateTeam(s *model.Situation) error {
 
 func (m *Service) TeamCreated(s *model.Situation) error {
 	rdb.SetPath(m.logger, m.rdb, s.User.ID, "created")
-	err := m.repo.CreateTeam(s.User.ID, s.Message.Text)
+	err := m.repo.As(s.User.ID).CreateTeam(s.User.ID, s.Message.Text)
 	if err != nil {
 		return err
 	}
 
+	err = m.menu.PublishAdmin(s.User.ID)
+	if err != nil {
+		m.logger.Error("publish admin menu", zap.Error(err))
+	}
+
 	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "team_created_successfully"))
 }
 
+// AddUserTeam adds the user to the team of the invite link they followed.
+// The reader passes the secret of the link as the argument.
 func (m *Service) AddUserTeam(s *model.Situation) error {
-	teamName, err := m.repo.AddUserToTeam(s.TeamID, s.User.ID)
+	invite := ""
+	if len(s.Args) > 0 {
+		invite = s.Args[0]
+	}
+
+	ok, err := m.allow(s.User.ID, policy.JoinTeam, policy.Resource{TeamID: s.TeamID, Invite: invite})
+	if err != nil || !ok {
+		return err
+	}
+
+	teamName, err := m.repo.As(s.User.ID).AddUserToTeam(s.TeamID, s.User.ID)
 	if err != nil {
 		return err
 	}
@@ -193,25 +234,28 @@ func (m *Service) AddUserTeam(s *model.Situation) error {
 }
 
 func (m *Service) DeadLine(s *model.Situation) error {
-	userID := rdb.GetTaskUserID(m.logger, m.rdb, s.User.ID)
-	id, err := strconv.ParseInt(userID, 10, 64)
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
 	if err != nil {
 		return err
 	}
 
-	complexity, err := strconv.Atoi(s.Message.Text)
+	complexity, err := utils.ParseComplexity(s.Message.Text)
 	if err != nil {
-		return err
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_complexity"))
 	}
 
-	err = m.repo.UpdateTaskComplexity(id, complexity)
+	err = m.repo.As(s.User.ID).UpdateTaskComplexity(taskID, complexity)
 	if err != nil {
 		return err
 	}
 
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/description")
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "priority")
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "send_deadline"))
+	markUp := tgbotapi.NewInlineKeyboardMarkup(utils.PriorityRow(m.texts, func(priority string) string {
+		return utils.CallbackData("/priority", priority)
+	}))
+
+	return m.SendPage(s.User.ID, utils.GetFormatText(m.texts, "choose_priority"), markUp)
 }
 
 func (m *Service) CheckTasks(s *model.Situation) error {
@@ -226,239 +270,1262 @@ func (m *Service) CheckTasks(s *model.Situation) error {
 		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "no_tasks_found"))
 	}
 
-	var text string
-	for i, val := range tasks {
-		text += strconv.Itoa(i) + ". " + utils.GetFormatText(m.texts, "task_info_id", val.ID, val.Complexity, val.Deadline.String(), val.Description) + "\n"
+	text, markUp := utils.TasksPage(m.texts, s.User.Location(), utils.ListTasks, utils.GetFormatText(m.texts, "tasks_page"), tasks, 0, config.C.PageSize)
+
+	return m.SendPage(s.User.ID, text, markUp)
+}
+
+// Next sends the card of the most pressing task of the user: the open task
+// with the nearest deadline once moved earlier by its priority.
+func (m *Service) Next(s *model.Situation) error {
+	task, err := m.repo.NextTask(s.User.ID)
+	if err != nil {
+		return err
 	}
 
-	markUp := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "delete_task"))))
+	if task == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "no_next_task"))
+	}
 
-	return m.SendMsgToUserWithMarkUp(s.User.ID, text, markUp)
+	text, markUp := utils.TaskCard(m.texts, s.User.Location(), task)
+	return m.SendPage(s.User.ID, utils.GetFormatText(m.texts, "next_task")+"\n\n"+text, markUp)
 }
 
-func (m *Service) DeleteTask(s *model.Situation) error {
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/task_deleted")
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_id"))
+// Tasks lists tasks with the filter given as arguments or the last used one.
+func (m *Service) Tasks(s *model.Situation) error {
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "tasks")
+
+	f := rdb.GetTaskFilter(m.logger, m.rdb, s.User.ID)
+	if len(s.Args) > 0 {
+		var err error
+		f, err = utils.ParseTaskFilter(s.Args, s.User.ID)
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "tasks_usage"))
+		}
+
+		rdb.SetTaskFilter(m.logger, m.rdb, s.User.ID, f)
+	}
+
+	tasks, err := m.repo.FilterTasks(s.User.ID, f)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.FilteredTasksPage(m.texts, s.User.Location(), f, s.User.ID, tasks, 0, config.C.PageSize)
+
+	return m.SendPage(s.User.ID, text, markUp)
 }
 
-func (m *Service) TaskDeleted(s *model.Situation) error {
-	taskID, err := strconv.Atoi(s.Message.Text)
+// Export offers a keyboard of export scopes and formats, or exports the tasks
+// matching "/export csv from=2026-10-01 to=2026-10-31" right away with the
+// filter of "/tasks".
+func (m *Service) Export(s *model.Situation) error {
+	if len(s.Args) == 0 {
+		return m.SendPage(s.User.ID, utils.GetFormatText(m.texts, "export_choose"), utils.ExportKeyboard(m.texts))
+	}
+
+	format, args := model.ExportCSV, s.Args
+	if slices.Contains(model.ExportFormats, strings.ToLower(args[0])) {
+		format, args = strings.ToLower(args[0]), args[1:]
+	}
+
+	f, err := utils.ParseTaskFilter(args, s.User.ID)
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "export_usage"))
+	}
+
+	tasks, err := m.repo.FilterTasks(s.User.ID, f)
 	if err != nil {
 		return err
 	}
-	err = m.repo.DeleteTask(taskID)
+
+	if len(tasks) == 0 {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "export_empty"))
+	}
+
+	doc, err := utils.ExportDocument(s.User.ID, format, s.User.Location(), tasks)
 	if err != nil {
 		return err
 	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_deleted"))
+	_, err = m.bot.Send(doc)
+	if err != nil {
+		return fmt.Errorf("send export: %w", err)
+	}
+
+	return nil
 }
 
-func (m *Service) TaskCreated(s *model.Situation) error {
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "task_created")
-	userID := rdb.GetTaskUserID(m.logger, m.rdb, s.User.ID)
-	id, err := strconv.ParseInt(userID, 10, 64)
+// Calendar sends the open tasks of the user as an iCalendar file together with
+// the link of the live feed. "/calendar reset" replaces the link.
+func (m *Service) Calendar(s *model.Situation) error {
+	reset := len(s.Args) > 0 && s.Args[0] == "reset"
+	if len(s.Args) > 0 && !reset {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "calendar_usage"))
+	}
+
+	login, err := m.repo.CheckUserRegister(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	err = m.repo.UpdateTaskDescription(id, s.Message.Text)
+	tasks, err := m.repo.CalendarTasks(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	task, err := m.repo.GetTaskInfo(id)
+	doc := tgbotapi.NewDocument(s.User.ID, tgbotapi.FileBytes{Name: "tasks.ics", Bytes: ical.Calendar(login, tasks, time.Now())})
+	doc.Caption = utils.GetFormatText(m.texts, "calendar_file", len(tasks))
+
+	if config.C.HTTP != nil && config.C.HTTP.PublicURL != "" {
+		token, err := m.calendarToken(s.User.ID, reset)
+		if err != nil {
+			return err
+		}
+
+		doc.Caption += "\n\n" + utils.GetFormatText(m.texts, "calendar_feed", ical.FeedURL(config.C.HTTP.PublicURL, token))
+	}
+
+	_, err = m.bot.Send(doc)
+	if err != nil {
+		return fmt.Errorf("send calendar: %w", err)
+	}
+
+	return nil
+}
+
+// calendarToken returns the secret of the calendar feed of the user, creating
+// a new one when there is none or reset is asked.
+func (m *Service) calendarToken(userID int64, reset bool) (string, error) {
+	token, err := m.repo.GetCalendarToken(userID)
+	if err != nil || (token != "" && !reset) {
+		return token, err
+	}
+
+	token, err = crypto.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	return token, m.repo.As(userID).UpdateCalendarToken(userID, token)
+}
+
+// History shows the latest writes to the team of its admin, or to one task
+// with "/history 12".
+func (m *Service) History(s *model.Situation) error {
+	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	err = m.SendMsgToUser(id, utils.GetFormatText(m.texts, "task_info_to_user", task.Complexity, task.Deadline.String(), task.Description))
+	taskID := 0
+	if len(s.Args) > 0 {
+		taskID, err = strconv.Atoi(strings.TrimPrefix(s.Args[0], "#"))
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "history_usage"))
+		}
+	}
+
+	entries, err := m.repo.AuditLog(&model.AuditQuery{TeamID: teamId, TaskID: taskID, Limit: utils.HistoryPageSize + 1})
 	if err != nil {
 		return err
 	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_info", task.Complexity, task.Deadline.String(), task.Description))
+	if len(entries) == 0 {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "history_empty"))
+	}
+
+	more := len(entries) > utils.HistoryPageSize
+	text, markUp := utils.HistoryPage(m.texts, s.User.Location(), taskID, entries[:min(len(entries), utils.HistoryPageSize)], more)
+	return m.SendPage(s.User.ID, text, markUp)
 }
 
-func (m *Service) Description(s *model.Situation) error {
-	userID := rdb.GetTaskUserID(m.logger, m.rdb, s.User.ID)
-	id, err := strconv.ParseInt(userID, 10, 64)
+// Import asks a team admin for a CSV file of tasks to create at once.
+func (m *Service) Import(s *model.Situation) error {
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/import_file")
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "import_usage"))
+}
+
+// ImportFile validates the uploaded CSV file and shows a preview of the
+// import with the errors of each row.
+func (m *Service) ImportFile(s *model.Situation) error {
+	doc := s.Message.Document
+	if doc == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "import_need_file"))
+	}
+
+	if doc.FileSize > utils.ImportMaxSize {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "import_file_too_large", utils.ImportMaxSize>>10))
+	}
+
+	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	timer := strings.ReplaceAll(s.Message.Text, "h", "")
-	deadline, err := strconv.Atoi(timer)
+	team, err := m.repo.YourTeam(teamId)
 	if err != nil {
 		return err
 	}
-	dur := time.Duration(int64(deadline)) * time.Hour
 
-	addDeadline := time.Now().Add(dur)
+	data, err := m.downloadFile(doc.FileID)
+	if err != nil {
+		return err
+	}
 
-	err = m.repo.UpdateTaskDeadline(id, addDeadline)
+	loc := s.User.Location()
+	rows, errs, err := utils.ParseImport(data, team.Users, time.Now().In(loc))
+	if errors.Is(err, utils.ErrImportTooLarge) {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "import_too_large", utils.ImportMaxRows))
+	}
 	if err != nil {
 		return err
 	}
 
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/task_created")
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "import_preview")
+	rdb.SetImportRows(m.logger, m.rdb, s.User.ID, rows)
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "send_description"))
+	text, markUp := utils.ImportPreview(m.texts, loc, rows, errs)
+	return m.SendPage(s.User.ID, text, markUp)
 }
 
-func (m *Service) Complexity(s *model.Situation) error {
-	userID, err := strconv.ParseInt(s.Message.Text, 10, 64)
+// downloadFile reads a file the user sent to the bot.
+func (m *Service) downloadFile(fileID string) ([]byte, error) {
+	url, err := m.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get file url: %w", err)
+	}
+
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, utils.ImportMaxSize))
+}
+
+// Backlog lists the unassigned tasks of the user's team that members can claim.
+func (m *Service) Backlog(s *model.Situation) error {
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "backlog")
+
+	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
-	err = m.repo.AddUserToTaskBar(userID)
+
+	tasks, err := m.repo.TeamBacklog(teamId)
 	if err != nil {
 		return err
 	}
 
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/deadline")
-	rdb.SetTaskUserID(m.logger, m.rdb, s.User.ID, userID)
+	if tasks == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "backlog_empty"))
+	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "complexity"))
+	text, markUp := utils.TasksPage(m.texts, s.User.Location(), utils.ListBacklog, utils.GetFormatText(m.texts, "backlog_page"), tasks, 0, config.C.PageSize)
+	return m.SendPage(s.User.ID, text, markUp)
 }
 
-func (m *Service) CreateTask(s *model.Situation) error {
-	teamId, err := m.repo.CheckTeam(s.User.ID)
+// Search finds tasks by the words of "/search <query>".
+func (m *Service) Search(s *model.Situation) error {
+	query := strings.Join(s.Args, " ")
+	if query == "" {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "search_usage"))
+	}
+
+	rdb.SetSearchQuery(m.logger, m.rdb, s.User.ID, query)
+
+	words, labels := utils.SplitSearchQuery(query)
+	results, err := m.repo.SearchTasks(s.User.ID, words, labels)
+	if err != nil {
+		return err
+	}
+
+	if results == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "search_not_found", query))
+	}
+
+	text, markUp := utils.SearchPage(m.texts, s.User.Location(), query, results, 0, config.C.PageSize)
+
+	return m.SendPage(s.User.ID, text, markUp)
+}
+
+func (m *Service) DeleteTask(s *model.Situation) error {
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/task_deleted")
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_id"))
+}
+
+// TaskDeleted moves the task with the entered ID to the trash when the user
+// may delete it and offers to undo the deletion.
+func (m *Service) TaskDeleted(s *model.Situation) error {
+	taskID, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(s.Message.Text), "#"))
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_id"))
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "task_deleted")
+
+	ok, err := m.allow(s.User.ID, policy.ManageTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	err = m.repo.As(s.User.ID).DeleteTask(taskID)
+	if errors.Is(err, repository.ErrTaskDeleted) {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_already_deleted", taskID))
+	}
+	if err != nil {
+		return err
+	}
+
+	return m.SendPage(s.User.ID, utils.GetFormatText(m.texts, "task_deleted_undo", taskID, utils.FormatDuration(m.texts, config.C.UndoWindow)), utils.UndoDelete(m.texts, taskID))
+}
+
+func (m *Service) TaskCreated(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	// The description may come as the caption of a file attached right away.
+	text := s.Message.Text
+	attachment := utils.MessageAttachment(s.Message)
+	if attachment != nil {
+		text = s.Message.Caption
+	}
+
+	description, err := utils.ParseDescription(text)
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_description"))
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "task_created")
+
+	err = m.repo.As(s.User.ID).UpdateTaskDescription(taskID, description)
 	if err != nil {
 		return err
 	}
 
-	if teamId == 0 {
-		err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "team_need_create"))
+	if attachment != nil {
+		attachment.TaskID, attachment.UserID = taskID, s.User.ID
+		err = m.repo.As(s.User.ID).AddAttachment(attachment)
 		if err != nil {
 			return err
 		}
+	}
 
-		return nil
+	err = m.repo.As(s.User.ID).UpdateTaskStatus(taskID, model.TaskOpen)
+	if err != nil {
+		return err
 	}
 
-	team, err := m.repo.YourTeam(teamId)
+	task, err := m.repo.GetTaskInfo(taskID)
 	if err != nil {
 		return err
 	}
 
-	var text string
-	for i, user := range team.Users {
-		uID := strconv.FormatInt(user.ID, 10)
-		text += strconv.Itoa(i) + ". " + user.Login + "\n" + uID + "\n"
+	if task.UserID == 0 {
+		err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "backlog_task_info", task.ID, task.Complexity, utils.FormatDeadline(m.texts, task.Deadline, s.User.Location()), task.Description))
+	} else {
+		err = m.SendMsgToUser(task.UserID, utils.GetFormatText(m.texts, "task_info_to_user", task.Complexity, utils.FormatDeadline(m.texts, task.Deadline, m.location(task.UserID)), task.Description))
+		if err != nil {
+			return err
+		}
+
+		err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_info", task.Complexity, utils.FormatDeadline(m.texts, task.Deadline, s.User.Location()), task.Description))
+	}
+	if err != nil {
+		return err
 	}
 
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/complexity")
+	return m.offerLabels(s.User.ID, task)
+}
+
+// offerLabels lets the creator of a new task pick its labels when the team has any.
+func (m *Service) offerLabels(userID int64, task *model.Tasks) error {
+	labels, err := m.repo.TeamLabels(task.TeamID)
+	if err != nil || labels == nil {
+		return err
+	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "choose_user_to_add_task", text))
+	return m.SendPage(userID, utils.GetFormatText(m.texts, "choose_labels", task.ID), utils.LabelToggles(m.texts, task, labels))
 }
 
-func (m *Service) DeleteUser(s *model.Situation) error {
+// Labels shows the label vocabulary of the team. Team admins change it with
+// "/labels add backend infra" and "/labels remove infra".
+func (m *Service) Labels(s *model.Situation) error {
 	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	if teamId == 0 {
-		err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "team_need_create"))
-		if err != nil {
+	if len(s.Args) > 0 {
+		ok, err := m.allow(s.User.ID, policy.ChangeLabels, policy.Resource{})
+		if err != nil || !ok {
 			return err
 		}
 
-		return nil
+		names, err := utils.ParseLabels(s.Args[1:])
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "labels_usage"))
+		}
+
+		switch s.Args[0] {
+		case "add":
+			err = m.repo.As(s.User.ID).AddTeamLabels(teamId, names)
+		case "remove":
+			err = m.repo.As(s.User.ID).RemoveTeamLabels(teamId, names)
+		default:
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "labels_usage"))
+		}
+		if err != nil {
+			return err
+		}
 	}
 
-	team, err := m.repo.YourTeam(teamId)
+	labels, err := m.repo.TeamLabels(teamId)
 	if err != nil {
 		return err
 	}
 
-	var text string
-	for i, user := range team.Users {
-		uID := strconv.FormatInt(user.ID, 10)
-		text = strconv.Itoa(i) + ". " + user.Login + "\n" + uID + "\n"
+	if labels == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "labels_empty"))
 	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "delete_user_text", text))
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "team_labels", utils.LabelNames(labels)))
 }
-func (m *Service) ExitTeam(s *model.Situation) error {
+
+// Description parses the deadline of a new task and asks to confirm it before
+// the description is requested.
+func (m *Service) Description(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	due, err := deadline.Parse(s.Message.Text, time.Now().In(s.User.Location()))
+	if err != nil {
+		return m.invalidDeadline(s.User.ID, err)
+	}
+
+	rdb.SetPendingDeadline(m.logger, m.rdb, s.User.ID, taskID, due)
+
 	markUp := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "yes"), "/yes"),
-			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "no"), "/no")))
-	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "you_sure"), markUp)
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "yes"), utils.CallbackData("/deadline_ok", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "no"), "/deadline_retry")))
+
+	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "confirm_deadline", utils.FormatDeadline(m.texts, due, s.User.Location())), markUp)
 }
 
-func (m *Service) DeletedUser(s *model.Situation) error {
-	id, err := strconv.ParseInt(s.Message.Text, 10, 64)
+func (m *Service) invalidDeadline(userID int64, err error) error {
+	if errors.Is(err, deadline.ErrPast) {
+		return m.SendMsgToUser(userID, utils.GetFormatText(m.texts, "deadline_in_past"))
+	}
+
+	return m.SendMsgToUser(userID, utils.GetFormatText(m.texts, "invalid_deadline"))
+}
+
+func (m *Service) Complexity(s *model.Situation) error {
+	userID, err := strconv.ParseInt(s.Message.Text, 10, 64)
 	if err != nil {
 		return err
 	}
-	err = m.repo.DeleteUserFromTeam(id)
+
+	ok, err := m.allow(s.User.ID, policy.AssignTask, policy.Resource{UserID: userID})
+	if err != nil || !ok {
+		return err
+	}
+
+	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "user_deleted"))
-}
+	taskID, err := m.repo.As(s.User.ID).AddUserToTaskBar(userID, s.User.ID, teamId)
+	if err != nil {
+		return err
+	}
 
-func (m *Service) AddUser(s *model.Situation) error {
-	teamId, err := m.repo.CheckTeam(s.User.ID)
+	err = m.repo.As(s.User.ID).UpdateLastAssignee(teamId, userID)
 	if err != nil {
 		return err
 	}
 
-	link := config.C.BotLink + "?start=new_team_user_" + strconv.Itoa(teamId)
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/deadline")
+	rdb.SetTaskUserID(m.logger, m.rdb, s.User.ID, userID)
+	rdb.SetTaskID(m.logger, m.rdb, s.User.ID, taskID)
 
-	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "send_link", link))
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "complexity"))
 }
 
-func (m *Service) YourTeam(s *model.Situation) error {
-	rdb.SetPath(m.logger, m.rdb, s.User.ID, "your_team")
+func (m *Service) CreateTask(s *model.Situation) error {
 	teamId, err := m.repo.CheckTeam(s.User.ID)
 	if err != nil {
 		return err
 	}
 
-	if teamId == 0 {
-		err = m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "team_need_create"))
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-
 	team, err := m.repo.YourTeam(teamId)
 	if err != nil {
 		return err
 	}
 
-	var text string
-	for i, user := range team.Users {
-		text += strconv.Itoa(i) + ". " + user.Login + "\n"
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/complexity")
+
+	text, markUp := utils.TeamPage(m.texts, utils.ListCreateTask, team, 0, config.C.PageSize)
+
+	err = m.SendPage(s.User.ID, text, markUp)
+	if err != nil {
+		return err
 	}
 
-	markUp := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "add_user")),
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "delete_user"))),
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "exit_team"))))
-	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "team_info", team.Name, text), markUp)
+	return m.suggestAssignee(s.User.ID, teamId)
 }
 
-func (m *Service) Team(s *model.Situation) error {
-	markUp := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "create_team")),
-			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "your_team"))))
+// suggestAssignee sends the assignee the team policy suggests together with
+// the load of each member. Teams assigning manually get nothing.
+func (m *Service) suggestAssignee(userID int64, teamID int) error {
+	policy, lastID, err := m.repo.TeamPolicy(teamID)
+	if err != nil || policy == model.AssignManual {
+		return err
+	}
+
+	members, err := m.repo.MemberLoads(teamID)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.AssignSuggestion(m.texts, policy, members, assign.Suggest(policy, members, lastID))
+	return m.SendPage(userID, text, markUp)
+}
+
+// AssignPolicy shows the assignment policy of the team with the member loads
+// and lets the team admin switch it.
+func (m *Service) AssignPolicy(s *model.Situation) error {
+	teamId, err := m.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	current, lastID, err := m.repo.TeamPolicy(teamId)
+	if err != nil {
+		return err
+	}
+
+	members, err := m.repo.MemberLoads(teamId)
+	if err != nil {
+		return err
+	}
+
+	text := utils.GetFormatText(m.texts, "assign_policy_info", utils.GetFormatText(m.texts, "assign_"+current)) + "\n" + utils.MemberLoads(m.texts, members, assign.Suggest(current, members, lastID))
+
+	err = m.policy.Check(s.User.ID, policy.ChangePolicy, policy.Resource{})
+	if errors.As(err, new(*policy.Denied)) {
+		return m.SendMsgToUser(s.User.ID, text)
+	}
+	if err != nil {
+		return err
+	}
+
+	return m.SendPage(s.User.ID, text, utils.PolicyToggles(m.texts, current))
+}
+
+// Report sends the weekly workload report of the team to its admin on demand.
+// "/report schedule fri 17:00" moves the scheduled report, in the admin's time
+// zone, and "/report schedule off" stops it.
+func (m *Service) Report(s *model.Situation) error {
+	teamId, err := m.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(s.Args) > 0 {
+		return m.reportSchedule(s, teamId)
+	}
+
+	name, err := m.repo.TeamName(teamId)
+	if err != nil {
+		return err
+	}
+
+	reports, err := m.repo.WorkloadReport(teamId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	schedule, err := m.repo.GetReportSchedule(teamId)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.FormatReport(m.texts, name, reports)+"\n\n"+utils.FormatReportSchedule(m.texts, schedule))
+}
+
+func (m *Service) reportSchedule(s *model.Situation, teamID int) error {
+	schedule := &model.ReportSchedule{
+		TeamID:   teamID,
+		Time:     "09:00",
+		TimeZone: s.User.Location().String(),
+	}
+
+	switch {
+	case len(s.Args) == 2 && s.Args[0] == "schedule" && s.Args[1] == "off":
+	case len(s.Args) == 3 && s.Args[0] == "schedule":
+		day, ok := recurrence.ParseWeekday(s.Args[1])
+		at, err := time.Parse("15:04", s.Args[2])
+		if !ok || err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "report_usage"))
+		}
+
+		// ISO weekdays count Sunday as 7.
+		schedule.Day = (int(day)+6)%7 + 1
+		schedule.Time = at.Format("15:04")
+	default:
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "report_usage"))
+	}
+
+	err := m.repo.As(s.User.ID).UpdateReportSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.FormatReportSchedule(m.texts, schedule))
+}
+
+// Digest turns the morning digest on at a time of day in the user's time zone,
+// e.g. "/digest 08:30", or off with "/digest off".
+func (m *Service) Digest(s *model.Situation) error {
+	if len(s.Args) == 0 {
+		at, err := m.repo.GetDigestTime(s.User.ID)
+		if err != nil {
+			return err
+		}
+
+		if at == "" {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "digest_off"))
+		}
+
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "digest_on", at, s.User.Location().String()))
+	}
+
+	at := ""
+	if s.Args[0] != "off" {
+		t, err := time.Parse("15:04", s.Args[0])
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "digest_usage"))
+		}
+
+		at = t.Format("15:04")
+	}
+
+	err := m.repo.As(s.User.ID).UpdateDigestTime(s.User.ID, at)
+	if err != nil {
+		return err
+	}
+
+	if at == "" {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "digest_off"))
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "digest_on", at, s.User.Location().String()))
+}
+
+// Available marks the user as available for new team tasks or away, e.g.
+// "/available off" during a vacation.
+func (m *Service) Available(s *model.Situation) error {
+	if len(s.Args) != 1 || (s.Args[0] != "on" && s.Args[0] != "off") {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "available_usage"))
+	}
+
+	available := s.Args[0] == "on"
+	err := m.repo.As(s.User.ID).UpdateAvailable(s.User.ID, available)
+	if err != nil {
+		return err
+	}
+
+	if available {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "available_on"))
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "available_off"))
+}
+
+func (m *Service) DeleteUser(s *model.Situation) error {
+	teamId, err := m.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	team, err := m.repo.YourTeam(teamId)
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "/user_deleted")
+
+	text, markUp := utils.TeamPage(m.texts, utils.ListDeleteUser, team, 0, config.C.PageSize)
 
+	return m.SendPage(s.User.ID, text, markUp)
+}
+func (m *Service) ExitTeam(s *model.Situation) error {
+	markUp := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "yes"), "/yes"),
+			tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "no"), "/no")))
+	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "you_sure"), markUp)
+}
+
+func (m *Service) DeletedUser(s *model.Situation) error {
+	id, err := strconv.ParseInt(s.Message.Text, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	ok, err := m.allow(s.User.ID, policy.RemoveMember, policy.Resource{UserID: id})
+	if err != nil || !ok {
+		return err
+	}
+
+	err = m.repo.As(s.User.ID).DeleteUserFromTeam(id)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "user_deleted"))
+}
+
+// AddUser sends the invite link of the team. "/add_user reset" replaces the
+// link, revoking the one given out before.
+func (m *Service) AddUser(s *model.Situation) error {
+	teamId, err := m.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := m.inviteToken(s.User.ID, teamId, len(s.Args) > 0 && s.Args[0] == "reset")
+	if err != nil {
+		return err
+	}
+
+	link := config.C.BotLink + "?start=new_team_user_" + strconv.Itoa(teamId) + "_" + token
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "send_link", link))
+}
+
+// inviteToken returns the secret of the invite link of the team, creating a
+// new one when there is none or reset is asked.
+func (m *Service) inviteToken(userID int64, teamID int, reset bool) (string, error) {
+	token, err := m.repo.GetInviteToken(teamID)
+	if err != nil || (token != "" && !reset) {
+		return token, err
+	}
+
+	token, err = crypto.NewInviteToken()
+	if err != nil {
+		return "", err
+	}
+
+	return token, m.repo.As(userID).UpdateInviteToken(teamID, token)
+}
+
+func (m *Service) YourTeam(s *model.Situation) error {
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "your_team")
+	teamId, err := m.repo.CheckTeam(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	team, err := m.repo.YourTeam(teamId)
+	if err != nil {
+		return err
+	}
+
+	text, pageMarkUp := utils.TeamPage(m.texts, utils.ListTeam, team, 0, config.C.PageSize)
+	err = m.SendPage(s.User.ID, text, pageMarkUp)
+	if err != nil {
+		return err
+	}
+
+	markUp := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "add_user")),
+			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "delete_user"))),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "exit_team"))))
 	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "choose"), markUp)
 }
 
+func (m *Service) Team(s *model.Situation) error {
+	markUp := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "create_team")),
+			tgbotapi.NewKeyboardButton(utils.GetFormatText(m.texts, "your_team"))))
+
+	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "choose"), markUp)
+}
+
+// EditTask starts editing the task given as "/edit_task <id>".
+func (m *Service) EditTask(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "edit_task_usage"))
+	}
+
+	task, err := m.repo.GetTaskInfo(taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_not_found"))
+		}
+		return err
+	}
+
+	ok, err := m.allow(s.User.ID, policy.EditTask, policy.Resource{TaskID: task.ID})
+	if err != nil || !ok {
+		return err
+	}
+
+	return m.SendMsgToUserWithMarkUp(s.User.ID, utils.GetFormatText(m.texts, "choose_edit_field", task.ID), utils.EditFields(m.texts, task.ID))
+}
+
+// CardEdited validates the new value of the field chosen on a task card,
+// saves it, records the change and restores the card in place.
+func (m *Service) CardEdited(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	messageID, err := strconv.Atoi(rdb.GetCardMessageID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	ok, err := m.allow(s.User.ID, policy.EditTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	task, err := m.repo.GetTaskInfo(taskID)
+	if err != nil {
+		return err
+	}
+
+	change := &model.TaskChange{
+		TaskID: task.ID,
+		UserID: s.User.ID,
+		Field:  rdb.GetEditField(m.logger, m.rdb, s.User.ID),
+	}
+
+	switch change.Field {
+	case model.FieldComplexity:
+		complexity, err := utils.ParseComplexity(s.Message.Text)
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_complexity"))
+		}
+
+		err = m.repo.As(s.User.ID).UpdateTaskComplexity(task.ID, complexity)
+		if err != nil {
+			return err
+		}
+
+		change.Before, change.After = strconv.Itoa(task.Complexity), strconv.Itoa(complexity)
+		task.Complexity = complexity
+	case model.FieldDeadline:
+		due, err := deadline.Parse(s.Message.Text, time.Now().In(s.User.Location()))
+		if err != nil {
+			return m.invalidDeadline(s.User.ID, err)
+		}
+
+		rdb.SetPendingDeadline(m.logger, m.rdb, s.User.ID, task.ID, due)
+
+		markUp := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "yes"), utils.CallbackData("/card_deadline_ok", task.ID)),
+				tgbotapi.NewInlineKeyboardButtonData(utils.GetFormatText(m.texts, "no"), utils.CallbackData("/card_edit_field", task.ID, model.FieldDeadline))))
+
+		_, err = m.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(s.User.ID, messageID, utils.GetFormatText(m.texts, "confirm_deadline", utils.FormatDeadline(m.texts, due, s.User.Location())), markUp))
+		if err != nil {
+			return fmt.Errorf("edit card: %w", err)
+		}
+
+		return nil
+	case model.FieldDescription:
+		description, err := utils.ParseDescription(s.Message.Text)
+		if err != nil {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_description"))
+		}
+
+		err = m.repo.As(s.User.ID).UpdateTaskDescription(task.ID, description)
+		if err != nil {
+			return err
+		}
+
+		change.Before, change.After = task.Description, description
+		task.Description = description
+	default:
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "some_wrong"))
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "card_edited")
+
+	err = m.repo.As(s.User.ID).AddTaskChange(change)
+	if err != nil {
+		return err
+	}
+
+	notifyID := task.UserID
+	if s.User.ID == task.UserID {
+		notifyID = task.CreatorID
+	}
+
+	if notifyID != 0 && notifyID != s.User.ID {
+		field := utils.GetFormatText(m.texts, "field_"+change.Field)
+		err = m.SendMsgToUser(notifyID, utils.GetFormatText(m.texts, "task_changed", task.ID, field, change.Before, change.After))
+		if err != nil {
+			m.logger.Error("notify task change", zap.Error(err))
+		}
+	}
+
+	text, markUp := utils.TaskCard(m.texts, s.User.Location(), task)
+	_, err = m.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(s.User.ID, messageID, text, markUp))
+	if err != nil {
+		return fmt.Errorf("edit card: %w", err)
+	}
+
+	return nil
+}
+
+// CommentAdded saves the comment asked for by the comment button of a task card.
+func (m *Service) CommentAdded(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "comment_added")
+
+	return m.addComment(s, taskID)
+}
+
+// CommentReply saves a reply to a task card, or to a comment notification, as a
+// comment to the task. The reader passes the task ID found on the card.
+func (m *Service) CommentReply(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	return m.addComment(s, taskID)
+}
+
+// addComment stores the text of the message as a comment and delivers it to
+// the creator, the assignee and the watchers of the task except the author.
+func (m *Service) addComment(s *model.Situation, taskID int) error {
+	text := strings.TrimSpace(s.Message.Text)
+	if text == "" {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_comment"))
+	}
+
+	ok, err := m.allow(s.User.ID, policy.ViewTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	err = m.repo.As(s.User.ID).AddComment(&model.Comment{
+		TaskID: taskID,
+		UserID: s.User.ID,
+		Text:   text,
+	})
+	if err != nil {
+		return err
+	}
+
+	login, err := m.repo.CheckUserRegister(s.User.ID)
+	if err != nil {
+		return err
+	}
+
+	participants, err := m.repo.GetParticipants(taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range participants {
+		if userID == s.User.ID {
+			continue
+		}
+
+		err = m.SendPage(userID, utils.GetFormatText(m.texts, "comment_notify", taskID, login, text), utils.CommentNotice(m.texts, taskID))
+		if err != nil {
+			m.logger.Error("notify comment", zap.Int64("user", userID), zap.Error(err))
+		}
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "comment_added", taskID))
+}
+
+// ChecklistAdded appends the lines of the message to the checklist of the task
+// and shows the updated checklist.
+func (m *Service) ChecklistAdded(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	items := utils.ParseChecklist(s.Message.Text)
+	if items == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_checklist"))
+	}
+
+	ok, err := m.allow(s.User.ID, policy.EditTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	rdb.SetPath(m.logger, m.rdb, s.User.ID, "checklist_added")
+
+	err = m.repo.As(s.User.ID).AddTaskItems(taskID, items)
+	if err != nil {
+		return err
+	}
+
+	all, err := m.repo.GetTaskItems(taskID)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.ChecklistPage(m.texts, taskID, all, len(all)/utils.ChecklistPageSize)
+	return m.SendPage(s.User.ID, text, markUp)
+}
+
+// AttachmentAdded attaches the files sent after the attach button of a task card
+// until a message without a file ends the upload.
+func (m *Service) AttachmentAdded(s *model.Situation) error {
+	taskID, err := strconv.Atoi(rdb.GetTaskID(m.logger, m.rdb, s.User.ID))
+	if err != nil {
+		return err
+	}
+
+	if utils.MessageAttachment(s.Message) == nil {
+		rdb.SetPath(m.logger, m.rdb, s.User.ID, "attachment_added")
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "attachments_done", taskID))
+	}
+
+	return m.addAttachment(s, taskID)
+}
+
+// AttachmentReply attaches a file sent as a reply to a task card.
+func (m *Service) AttachmentReply(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil {
+		return err
+	}
+
+	return m.addAttachment(s, taskID)
+}
+
+func (m *Service) addAttachment(s *model.Situation, taskID int) error {
+	attachment := utils.MessageAttachment(s.Message)
+	if attachment == nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_attachment"))
+	}
+
+	ok, err := m.allow(s.User.ID, policy.ViewTask, policy.Resource{TaskID: taskID})
+	if err != nil || !ok {
+		return err
+	}
+
+	attachment.TaskID, attachment.UserID = taskID, s.User.ID
+	err = m.repo.As(s.User.ID).AddAttachment(attachment)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "attachment_added", utils.AttachmentName(m.texts, attachment), taskID))
+}
+
+// allow checks the action on the resource with the policy. It returns false
+// after telling the user why the action is denied.
+func (m *Service) allow(userID int64, action policy.Action, res policy.Resource) (bool, error) {
+	err := m.policy.Check(userID, action, res)
+	var denied *policy.Denied
+	if errors.As(err, &denied) {
+		return false, m.SendMsgToUser(userID, utils.GetFormatText(m.texts, denied.Key))
+	}
+
+	return err == nil, err
+}
+
+// SendPage sends a page of a list. An empty markUp sends the page without keyboard.
+func (m *Service) SendPage(userID int64, text string, markUp tgbotapi.InlineKeyboardMarkup) error {
+	msg := tgbotapi.NewMessage(userID, text)
+	if len(markUp.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = markUp
+	}
+
+	_, err := m.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("send page to user: %w", err)
+	}
+
+	return nil
+}
+
+// Repeat makes a task the first instance of a recurring series, e.g.
+// "/repeat 12 weekly mon,thu".
+func (m *Service) Repeat(s *model.Situation) error {
+	taskID, err := utils.IntArg(s.Args, 0)
+	if err != nil || len(s.Args) < 2 {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "repeat_usage"))
+	}
+
+	task, err := m.repo.GetTaskInfo(taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_not_found"))
+		}
+		return err
+	}
+
+	ok, err := m.allow(s.User.ID, policy.ManageSeries, policy.Resource{TaskID: task.ID})
+	if err != nil || !ok {
+		return err
+	}
+
+	if task.SeriesID != 0 {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_already_recurring"))
+	}
+
+	// The rule takes its weekday, day of month and time from the deadline.
+	if task.Deadline.IsZero() {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "repeat_no_deadline", task.ID))
+	}
+
+	rule, err := recurrence.Parse(strings.Join(s.Args[1:], " "), task.Deadline.In(s.User.Location()))
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_rule"))
+	}
+
+	_, err = m.repo.As(s.User.ID).CreateSeries(task.ID, s.User.ID, rule.String())
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "series_created", task.ID, utils.DescribeRule(m.texts, rule)))
+}
+
+// Block marks a task as waiting for other tasks of its team, e.g.
+// "/block 12 7 8" for task 12 blocked by tasks 7 and 8.
+func (m *Service) Block(s *model.Situation) error {
+	task, blockers, err := m.dependencyArgs(s, "block_usage")
+	if err != nil || task == nil {
+		return err
+	}
+
+	err = m.repo.As(s.User.ID).AddDependencies(task.ID, blockers)
+	var rejected *repository.DependencyError
+	switch {
+	case errors.As(err, &rejected) && errors.Is(err, repository.ErrDependencyCycle):
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "dependency_cycle", task.ID, rejected.BlockerID)+"\n"+utils.GetFormatText(m.texts, "dependency_none_saved"))
+	case errors.As(err, &rejected) && errors.Is(err, sql.ErrNoRows):
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_not_found")+"\n"+utils.GetFormatText(m.texts, "dependency_none_saved"))
+	case errors.As(err, &rejected) && errors.Is(err, repository.ErrTaskDeleted):
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "dependency_deleted", rejected.BlockerID)+"\n"+utils.GetFormatText(m.texts, "dependency_none_saved"))
+	case errors.As(err, &rejected):
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "dependency_other_team", rejected.BlockerID)+"\n"+utils.GetFormatText(m.texts, "dependency_none_saved"))
+	case err != nil:
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_blocked_saved", task.ID, utils.TaskIDs(blockers)))
+}
+
+// Unblock removes blockers of a task, e.g. "/unblock 12 7".
+func (m *Service) Unblock(s *model.Situation) error {
+	task, blockers, err := m.dependencyArgs(s, "unblock_usage")
+	if err != nil || task == nil {
+		return err
+	}
+
+	for _, blockerID := range blockers {
+		err = m.repo.As(s.User.ID).RemoveDependency(task.ID, blockerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_unblocked_saved", task.ID, utils.TaskIDs(blockers)))
+}
+
+// dependencyArgs parses "<task> <blocker>..." and loads the task, which only its
+// assignee or creator may change. It returns a nil task after answering the user.
+func (m *Service) dependencyArgs(s *model.Situation, usage string) (*model.Tasks, []int, error) {
+	if len(s.Args) < 2 {
+		return nil, nil, m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, usage))
+	}
+
+	ids := make([]int, 0, len(s.Args))
+	for i := range s.Args {
+		id, err := utils.IntArg(s.Args, i)
+		if err != nil {
+			return nil, nil, m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, usage))
+		}
+
+		ids = append(ids, id)
+	}
+
+	task, err := m.repo.GetTaskInfo(ids[0])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "task_not_found"))
+		}
+		return nil, nil, err
+	}
+
+	ok, err := m.allow(s.User.ID, policy.EditTask, policy.Resource{TaskID: task.ID})
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	return task, ids[1:], nil
+}
+
+// TimeZone shows or changes the time zone deadlines are read and shown in.
+func (m *Service) TimeZone(s *model.Situation) error {
+	if len(s.Args) == 0 {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "timezone_current", s.User.Location().String()))
+	}
+
+	tz, err := utils.ParseTimeZone(strings.Join(s.Args, " "))
+	if err != nil {
+		return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "invalid_timezone"))
+	}
+
+	err = m.repo.As(s.User.ID).UpdateUserTimeZone(s.User.ID, tz)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMsgToUser(s.User.ID, utils.GetFormatText(m.texts, "timezone_saved", tz))
+}
+
+// location returns the time zone of another user, e.g. the recipient of a notification.
+func (m *Service) location(userID int64) *time.Location {
+	tz, err := m.repo.GetUserTimeZone(userID)
+	if err != nil {
+		m.logger.Error("get user time zone", zap.Error(err))
+	}
+
+	return (&model.User{TimeZone: tz}).Location()
+}
+
 func (m *Service) SendMsgToUser(userID int64, text string) error {
 	msg := &tgbotapi.MessageConfig{
 		BaseChat: tgbotapi.BaseChat{
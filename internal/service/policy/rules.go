@@ -0,0 +1,208 @@
+package policy
+
+// Action is something a user asks the bot to do. Every command is registered
+// with the action it performs: the guard of the command checks the parts of
+// the rule about the actor and the handler the parts about the resource.
+type Action string
+
+const (
+	// Public commands need nothing, e.g. signing up.
+	Public Action = "public"
+	// Registered commands work on the user's own tasks and settings.
+	Registered Action = "registered"
+	// Member commands work on the user's team.
+	Member Action = "member"
+
+	JoinTeam     Action = "join_team"
+	InviteMember Action = "invite_member"
+	RemoveMember Action = "remove_member"
+	AssignTask   Action = "assign_task"
+	ChangePolicy Action = "change_policy"
+	ChangeLabels Action = "change_labels"
+	ViewReport   Action = "view_report"
+	ImportTasks  Action = "import_tasks"
+	ViewHistory  Action = "view_history"
+
+	ViewTask     Action = "view_task"
+	EditTask     Action = "edit_task"
+	ManageTask   Action = "manage_task"
+	ManageSeries Action = "manage_series"
+)
+
+// Access is how close the actor must be to the task of the resource.
+type Access int
+
+const (
+	// AccessNone needs no task.
+	AccessNone Access = iota
+	// AccessView lets in the assignee, the creator and the members of the
+	// task's team.
+	AccessView
+	// AccessEdit lets in the assignee and the creator.
+	AccessEdit
+	// AccessManage lets in the assignee, the creator and the admins of the
+	// task's team, also for tasks in the trash.
+	AccessManage
+	// AccessCreator lets in the creator only.
+	AccessCreator
+)
+
+// Rule lists what an action requires. Checks run in the order of the fields
+// and the first failed one is the reason of the denial.
+type Rule struct {
+	// Registered requires the actor to have signed up.
+	Registered bool
+	// Solo requires the actor not to be in a team yet.
+	Solo bool
+	// Member requires the actor to be in a team.
+	Member bool
+	// Admin requires the actor to be an admin of their team.
+	Admin bool
+	// Invite requires Resource.Invite to be the invite secret of
+	// Resource.TeamID.
+	Invite bool
+	// Target requires Resource.UserID to be in the actor's team.
+	Target bool
+	// NotSelf requires Resource.UserID not to be the actor.
+	NotSelf bool
+	// Task is the access needed to Resource.TaskID.
+	Task Access
+	// Deny is the text sent when the actor is not an admin or lacks the
+	// access to the task, instead of "denied_admin" or "not_your_task".
+	Deny string
+}
+
+// actorPart is the part of the rule the guard of a command checks before
+// the handler knows the resource.
+func (r Rule) actorPart() Rule {
+	return Rule{
+		Registered: r.Registered,
+		Solo:       r.Solo,
+		Member:     r.Member,
+		Admin:      r.Admin,
+		Deny:       r.Deny,
+	}
+}
+
+// rules is the whole policy. Actions missing from it are denied.
+var rules = map[Action]Rule{
+	Public:     {},
+	Registered: {Registered: true},
+	Member:     {Registered: true, Member: true},
+
+	JoinTeam:     {Registered: true, Solo: true, Invite: true},
+	InviteMember: {Registered: true, Member: true, Admin: true},
+	RemoveMember: {Registered: true, Member: true, Admin: true, Target: true, NotSelf: true},
+	AssignTask:   {Registered: true, Member: true, Target: true},
+	ChangePolicy: {Registered: true, Member: true, Admin: true, Deny: "assign_admin_only"},
+	ChangeLabels: {Registered: true, Member: true, Admin: true, Deny: "labels_admin_only"},
+	ViewReport:   {Registered: true, Member: true, Admin: true, Deny: "report_admin_only"},
+	ImportTasks:  {Registered: true, Member: true, Admin: true, Deny: "import_admin_only"},
+	ViewHistory:  {Registered: true, Member: true, Admin: true, Deny: "history_admin_only"},
+
+	ViewTask:   {Registered: true, Task: AccessView},
+	EditTask:   {Registered: true, Task: AccessEdit},
+	ManageTask: {Registered: true, Task: AccessManage},
+
+	ManageSeries: {Registered: true, Task: AccessCreator, Deny: "not_series_creator"},
+}
+
+// Commands is the action of every message command. The handlers are
+// registered with it and a command missing from it is never run.
+var Commands = map[string]Action{
+	"/start":            Public,
+	"/sign_up":          Public,
+	"/login":            Public,
+	"/password":         Public,
+	"/unrecognized":     Public,
+	"/team":             Registered,
+	"/create_team":      Registered,
+	"/team_created":     Registered,
+	"/your_team":        Member,
+	"/add_user":         InviteMember,
+	"/add_user_team":    JoinTeam,
+	"/delete_user":      RemoveMember,
+	"/user_deleted":     RemoveMember,
+	"/assign_policy":    Member,
+	"/report":           ViewReport,
+	"/import":           ImportTasks,
+	"/import_file":      ImportTasks,
+	"/history":          ViewHistory,
+	"/exit_team":        Member,
+	"/create_task":      Member,
+	"/complexity":       AssignTask,
+	"/deadline":         Member,
+	"/description":      Member,
+	"/task_created":     Member,
+	"/check_tasks":      Registered,
+	"/next":             Registered,
+	"/tasks":            Registered,
+	"/export":           Registered,
+	"/calendar":         Registered,
+	"/backlog":          Member,
+	"/labels":           Member,
+	"/available":        Member,
+	"/digest":           Registered,
+	"/search":           Registered,
+	"/timezone":         Registered,
+	"/repeat":           ManageSeries,
+	"/block":            EditTask,
+	"/unblock":          EditTask,
+	"/task_delete":      Registered,
+	"/task_deleted":     ManageTask,
+	"/edit_task":        EditTask,
+	"/card_edited":      EditTask,
+	"/checklist_added":  EditTask,
+	"/comment_added":    ViewTask,
+	"/comment_reply":    ViewTask,
+	"/attachment_added": ViewTask,
+	"/attachment_reply": ViewTask,
+}
+
+// Callbacks is the action of every inline button command.
+var Callbacks = map[string]Action{
+	"/yes":                Member,
+	"/no":                 Public,
+	"/page":               Registered,
+	"/filter":             Registered,
+	"/export":             Registered,
+	"/import_confirm":     ImportTasks,
+	"/import_cancel":      Registered,
+	"/history":            ViewHistory,
+	"/card":               ViewTask,
+	"/card_open":          ViewTask,
+	"/card_done":          EditTask,
+	"/card_edit":          EditTask,
+	"/card_edit_field":    EditTask,
+	"/card_deadline_ok":   EditTask,
+	"/card_priority":      EditTask,
+	"/priority":           Member,
+	"/deadline_ok":        Member,
+	"/deadline_retry":     Member,
+	"/card_delete":        ManageTask,
+	"/card_delete_yes":    ManageTask,
+	"/task_undo":          ManageTask,
+	"/card_reassign":      EditTask,
+	"/card_reassign_to":   AssignTask,
+	"/card_postpone":      EditTask,
+	"/card_postpone_by":   EditTask,
+	"/create_backlog":     Member,
+	"/assign_accept":      AssignTask,
+	"/assign_policy":      ChangePolicy,
+	"/card_claim":         ViewTask,
+	"/card_labels":        EditTask,
+	"/card_label":         EditTask,
+	"/card_checklist":     ViewTask,
+	"/card_checklist_add": EditTask,
+	"/card_item":          EditTask,
+	"/card_comments":      ViewTask,
+	"/card_comment":       ViewTask,
+	"/card_watch":         ViewTask,
+	"/card_attachments":   ViewTask,
+	"/card_attach":        ViewTask,
+	"/attachment_send":    ViewTask,
+	"/card_series":        EditTask,
+	"/series_pause":       ManageSeries,
+	"/series_resume":      ManageSeries,
+	"/series_end":         ManageSeries,
+}
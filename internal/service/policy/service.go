@@ -0,0 +1,171 @@
+package policy
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"tgbot/internal/model"
+)
+
+// Repository is where the policy reads its facts from.
+type Repository interface {
+	GetActor(userID int64) (*model.Actor, error)
+	GetTaskParties(taskID int) (*model.TaskParties, error)
+	GetInviteToken(teamID int) (string, error)
+	IsTeamMember(teamID int, userID int64) (bool, error)
+}
+
+// Resource is what an action is done to. Zero fields are not part of it.
+type Resource struct {
+	TeamID int
+	TaskID int
+	UserID int64
+	Invite string
+}
+
+// Denied is the error of an action the policy does not allow. Key is the
+// text telling the user why.
+type Denied struct {
+	Action Action
+	Key    string
+}
+
+func (d *Denied) Error() string {
+	return fmt.Sprintf("%s denied: %s", d.Action, d.Key)
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewPolicyService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// Check returns a *Denied error unless the rule of the action allows it to
+// the user on the resource. Only the facts the rule needs are loaded.
+func (p *Service) Check(userID int64, action Action, res Resource) error {
+	rule, ok := rules[action]
+	if !ok {
+		return &Denied{Action: action, Key: "denied"}
+	}
+
+	return p.check(userID, action, rule, res)
+}
+
+// Gate checks the parts of the rule of the action about the actor, for the
+// guard of a command that runs before the resource is known.
+func (p *Service) Gate(userID int64, action Action) error {
+	rule, ok := rules[action]
+	if !ok {
+		return &Denied{Action: action, Key: "denied"}
+	}
+
+	return p.check(userID, action, rule.actorPart(), Resource{})
+}
+
+func (p *Service) check(userID int64, action Action, rule Rule, res Resource) error {
+	if rule == (Rule{}) {
+		return nil
+	}
+
+	actor, err := p.repo.GetActor(userID)
+	if err != nil {
+		return fmt.Errorf("get actor: %w", err)
+	}
+
+	f := &facts{actor: actor}
+	if rule.Invite {
+		f.inviteToken, err = p.repo.GetInviteToken(res.TeamID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rule.Target && actor.TeamID != 0 {
+		f.targetMember, err = p.repo.IsTeamMember(actor.TeamID, res.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rule.Task != AccessNone {
+		f.task, err = p.repo.GetTaskParties(res.TaskID)
+		if err != nil {
+			return fmt.Errorf("get task %d: %w", res.TaskID, err)
+		}
+	}
+
+	key := evaluate(rule, f, res)
+	if key == "" {
+		return nil
+	}
+
+	return &Denied{Action: action, Key: key}
+}
+
+// facts are what the policy knows about the actor and the resource.
+type facts struct {
+	actor        *model.Actor
+	inviteToken  string
+	targetMember bool
+	task         *model.TaskParties
+}
+
+// evaluate returns the text key of the first requirement of the rule the
+// facts miss, or "" when the rule allows the action.
+func evaluate(rule Rule, f *facts, res Resource) string {
+	admin := f.actor.Role == model.RoleAdmin
+	switch {
+	case rule.Registered && !f.actor.Registered:
+		return "not_registered"
+	case rule.Solo && f.actor.TeamID != 0:
+		return "denied_in_team"
+	case rule.Member && f.actor.TeamID == 0:
+		return "team_need_create"
+	case rule.Admin && !admin:
+		if rule.Deny != "" {
+			return rule.Deny
+		}
+		return "denied_admin"
+	case rule.Invite && (f.inviteToken == "" || subtle.ConstantTimeCompare([]byte(f.inviteToken), []byte(res.Invite)) != 1):
+		return "denied_team_link"
+	case rule.Target && !f.targetMember:
+		return "not_team_member"
+	case rule.NotSelf && res.UserID == f.actor.ID:
+		return "denied_self"
+	case rule.Task != AccessNone && !taskAccess(rule.Task, f.actor, f.task):
+		if rule.Deny != "" {
+			return rule.Deny
+		}
+		return "not_your_task"
+	}
+
+	return ""
+}
+
+func taskAccess(need Access, actor *model.Actor, task *model.TaskParties) bool {
+	if task == nil || (task.Deleted && need != AccessManage) {
+		return false
+	}
+
+	if need == AccessCreator {
+		return task.CreatorID == actor.ID
+	}
+
+	if task.UserID == actor.ID || task.CreatorID == actor.ID {
+		return true
+	}
+
+	inTeam := task.TeamID != 0 && task.TeamID == actor.TeamID
+	switch need {
+	case AccessView:
+		return inTeam
+	case AccessManage:
+		return inTeam && actor.Role == model.RoleAdmin
+	}
+
+	return false
+}
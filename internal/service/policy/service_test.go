@@ -0,0 +1,255 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"tgbot/internal/model"
+)
+
+// fakeRepository keeps the facts of the policy in memory. Users missing
+// from actors have not signed up.
+type fakeRepository struct {
+	actors  map[int64]*model.Actor
+	tasks   map[int]*model.TaskParties
+	invites map[int]string
+}
+
+func (r *fakeRepository) GetActor(userID int64) (*model.Actor, error) {
+	actor, ok := r.actors[userID]
+	if !ok {
+		return &model.Actor{ID: userID}, nil
+	}
+
+	copied := *actor
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetTaskParties(taskID int) (*model.TaskParties, error) {
+	task, ok := r.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *task
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetInviteToken(teamID int) (string, error) {
+	return r.invites[teamID], nil
+}
+
+func (r *fakeRepository) IsTeamMember(teamID int, userID int64) (bool, error) {
+	actor, ok := r.actors[userID]
+	return ok && actor.TeamID == teamID, nil
+}
+
+const (
+	unregistered int64 = iota + 1
+	solo
+	member
+	admin
+	assignee
+	creator
+	outsider
+)
+
+var actorNames = map[int64]string{
+	unregistered: "unregistered",
+	solo:         "solo",
+	member:       "member",
+	admin:        "admin",
+	assignee:     "assignee",
+	creator:      "creator",
+	outsider:     "outsider",
+}
+
+const (
+	team       = 1
+	otherTeam  = 2
+	task       = 10
+	inviteCode = "0123456789abcdef0123456789abcdef"
+)
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		actors: map[int64]*model.Actor{
+			solo:     {ID: solo, Registered: true},
+			member:   {ID: member, Registered: true, TeamID: team, Role: model.RoleMember},
+			admin:    {ID: admin, Registered: true, TeamID: team, Role: model.RoleAdmin},
+			assignee: {ID: assignee, Registered: true, TeamID: team, Role: model.RoleMember},
+			creator:  {ID: creator, Registered: true, TeamID: team, Role: model.RoleMember},
+			outsider: {ID: outsider, Registered: true, TeamID: otherTeam, Role: model.RoleMember},
+		},
+		tasks: map[int]*model.TaskParties{
+			task: {UserID: assignee, CreatorID: creator, TeamID: team},
+		},
+		invites: map[int]string{team: inviteCode},
+	}
+}
+
+// expected is the text key of the denial of every actor, "" when allowed.
+type expected map[int64]string
+
+// allowed lists the actors allowed, the others are denied with key.
+func allowed(key string, actors ...int64) expected {
+	e := expected{unregistered: "not_registered"}
+	for id := range actorNames {
+		if id != unregistered {
+			e[id] = key
+		}
+	}
+	for _, id := range actors {
+		e[id] = ""
+	}
+
+	return e
+}
+
+// teamOnly is like allowed, for actions telling a solo user to create a team.
+func teamOnly(key string, actors ...int64) expected {
+	e := allowed(key, actors...)
+	e[solo] = "team_need_create"
+
+	return e
+}
+
+var matrix = map[Action]expected{
+	Public:     {unregistered: "", solo: "", member: "", admin: "", assignee: "", creator: "", outsider: ""},
+	Registered: allowed("", solo),
+	Member:     teamOnly(""),
+
+	JoinTeam:     allowed("denied_in_team", solo),
+	InviteMember: teamOnly("denied_admin", admin),
+	RemoveMember: teamOnly("denied_admin", admin),
+	AssignTask:   teamOnly("", member, admin, assignee, creator).with(outsider, "not_team_member"),
+	ChangePolicy: teamOnly("assign_admin_only", admin),
+	ChangeLabels: teamOnly("labels_admin_only", admin),
+	ViewReport:   teamOnly("report_admin_only", admin),
+	ImportTasks:  teamOnly("import_admin_only", admin),
+	ViewHistory:  teamOnly("history_admin_only", admin),
+
+	ViewTask:     allowed("not_your_task", member, admin, assignee, creator),
+	EditTask:     allowed("not_your_task", assignee, creator),
+	ManageTask:   allowed("not_your_task", admin, assignee, creator),
+	ManageSeries: allowed("not_series_creator", creator),
+}
+
+func (e expected) with(id int64, key string) expected {
+	e[id] = key
+
+	return e
+}
+
+func checkKey(err error) (string, error) {
+	if err == nil {
+		return "", nil
+	}
+
+	var denied *Denied
+	if !errors.As(err, &denied) {
+		return "", err
+	}
+
+	return denied.Key, nil
+}
+
+func TestCheckCommandMatrix(t *testing.T) {
+	p := NewPolicyService(newFakeRepository())
+	res := Resource{TeamID: team, TaskID: task, UserID: member, Invite: inviteCode}
+
+	for kind, commands := range map[string]map[string]Action{"command": Commands, "callback": Callbacks} {
+		for command, action := range commands {
+			want, ok := matrix[action]
+			if !ok {
+				t.Errorf("%s %s: no expectations for action %s", kind, command, action)
+				continue
+			}
+
+			for id, name := range actorNames {
+				key, err := checkKey(p.Check(id, action, res))
+				if err != nil {
+					t.Errorf("%s %s as %s: %v", kind, command, name, err)
+					continue
+				}
+
+				if key != want[id] {
+					t.Errorf("%s %s (%s) as %s: denied %q, want %q", kind, command, action, name, key, want[id])
+				}
+			}
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	repo := newFakeRepository()
+	repo.tasks[task+1] = &model.TaskParties{UserID: assignee, CreatorID: creator, TeamID: team, Deleted: true}
+	p := NewPolicyService(repo)
+
+	tests := []struct {
+		name   string
+		userID int64
+		action Action
+		res    Resource
+		want   string
+	}{
+		{name: "wrong invite", userID: solo, action: JoinTeam, res: Resource{TeamID: team, Invite: "guess"}, want: "denied_team_link"},
+		{name: "no invite", userID: solo, action: JoinTeam, res: Resource{TeamID: team}, want: "denied_team_link"},
+		{name: "team without invite", userID: solo, action: JoinTeam, res: Resource{TeamID: otherTeam}, want: "denied_team_link"},
+		{name: "unknown team", userID: solo, action: JoinTeam, res: Resource{TeamID: 99, Invite: inviteCode}, want: "denied_team_link"},
+		{name: "remove self", userID: admin, action: RemoveMember, res: Resource{UserID: admin}, want: "denied_self"},
+		{name: "remove outsider", userID: admin, action: RemoveMember, res: Resource{UserID: outsider}, want: "not_team_member"},
+		{name: "assign outsider", userID: member, action: AssignTask, res: Resource{UserID: outsider}, want: "not_team_member"},
+		{name: "view deleted", userID: assignee, action: ViewTask, res: Resource{TaskID: task + 1}, want: "not_your_task"},
+		{name: "edit deleted", userID: creator, action: EditTask, res: Resource{TaskID: task + 1}, want: "not_your_task"},
+		{name: "manage deleted", userID: admin, action: ManageTask, res: Resource{TaskID: task + 1}},
+		{name: "series of deleted", userID: creator, action: ManageSeries, res: Resource{TaskID: task + 1}, want: "not_series_creator"},
+		{name: "missing task", userID: admin, action: ManageTask, res: Resource{TaskID: 99}, want: "not_your_task"},
+		{name: "unknown action", userID: admin, action: "drop_tables", want: "denied"},
+	}
+
+	for _, tt := range tests {
+		key, err := checkKey(p.Check(tt.userID, tt.action, tt.res))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if key != tt.want {
+			t.Errorf("%s: denied %q, want %q", tt.name, key, tt.want)
+		}
+	}
+}
+
+func TestGate(t *testing.T) {
+	p := NewPolicyService(newFakeRepository())
+
+	tests := []struct {
+		userID int64
+		action Action
+		want   string
+	}{
+		// The guard does not know the task, the handler checks it.
+		{userID: solo, action: EditTask},
+		{userID: outsider, action: ManageSeries},
+		{userID: solo, action: JoinTeam},
+		{userID: unregistered, action: EditTask, want: "not_registered"},
+		{userID: member, action: JoinTeam, want: "denied_in_team"},
+		{userID: member, action: InviteMember, want: "denied_admin"},
+		{userID: member, action: ViewReport, want: "report_admin_only"},
+		{userID: solo, action: AssignTask, want: "team_need_create"},
+		{userID: admin, action: "drop_tables", want: "denied"},
+	}
+
+	for _, tt := range tests {
+		key, err := checkKey(p.Gate(tt.userID, tt.action))
+		if err != nil {
+			t.Errorf("Gate(%s, %s): %v", actorNames[tt.userID], tt.action, err)
+			continue
+		}
+
+		if key != tt.want {
+			t.Errorf("Gate(%s, %s): denied %q, want %q", actorNames[tt.userID], tt.action, key, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package purge
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"tgbot/internal/repository"
+)
+
+type Service struct {
+	log       *zap.Logger
+	repo      *repository.PGRepository
+	retention time.Duration
+}
+
+func NewPurgeService(log *zap.Logger, repo *repository.PGRepository, retention time.Duration) *Service {
+	return &Service{
+		log:       log,
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// PurgeDeleted removes the tasks that have been in the trash longer than the retention.
+func (p *Service) PurgeDeleted() error {
+	n, err := p.repo.PurgeDeletedTasks(p.retention)
+	if err != nil {
+		return fmt.Errorf("purge deleted tasks: %w", err)
+	}
+
+	if n > 0 {
+		p.log.Info("purged deleted tasks", zap.Int64("count", n))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package recurring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"tgbot/internal/model"
+	"tgbot/internal/pkg/recurrence"
+	"tgbot/internal/pkg/utils"
+	"tgbot/internal/repository"
+)
+
+type Service struct {
+	log   *zap.Logger
+	texts map[string]string
+	bot   *tgbotapi.BotAPI
+	repo  *repository.PGRepository
+}
+
+func NewRecurringService(log *zap.Logger, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Service {
+	return &Service{
+		log:   log,
+		repo:  repo,
+		bot:   bot,
+		texts: texts,
+	}
+}
+
+// SpawnNext creates the next instance of every series whose current instance
+// is done or due, and ends series whose rule has no more occurrences.
+func (r *Service) SpawnNext() error {
+	tasks, err := r.repo.DueSeriesTasks()
+	if err != nil {
+		return fmt.Errorf("get due series tasks: %w", err)
+	}
+
+	for _, task := range tasks {
+		err = r.spawn(task)
+		if err != nil {
+			r.log.Error("spawn series task", zap.Int("task", task.ID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (r *Service) spawn(task *model.Tasks) error {
+	series, err := r.repo.GetSeries(task.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	loc := (&model.User{TimeZone: series.TimeZone}).Location()
+	rule, err := recurrence.Parse(series.Rule, task.Deadline.In(loc))
+	if err != nil {
+		return err
+	}
+
+	// A late instance is followed by the first occurrence after now, not
+	// by every occurrence missed meanwhile.
+	next := rule.Next(task.Deadline.In(loc))
+	for !next.IsZero() && !next.After(time.Now()) {
+		next = rule.Next(next)
+	}
+
+	if next.IsZero() {
+		return r.repo.UpdateSeriesStatus(series.ID, model.SeriesEnded)
+	}
+
+	taskID, err := r.repo.SpawnSeriesTask(task, next)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadySpawned) {
+			return nil
+		}
+		return err
+	}
+
+	created, err := r.repo.GetTaskInfo(taskID)
+	if err != nil {
+		return err
+	}
+
+	// A task of the team backlog goes to its creator until someone claims it.
+	recipient := created.UserID
+	if recipient == 0 {
+		recipient = created.CreatorID
+	}
+
+	tz, err := r.repo.GetUserTimeZone(recipient)
+	if err != nil {
+		return err
+	}
+
+	text, markUp := utils.TaskCard(r.texts, (&model.User{TimeZone: tz}).Location(), created)
+	msg := tgbotapi.NewMessage(recipient, utils.GetFormatText(r.texts, "series_next_task")+"\n\n"+text)
+	msg.ReplyMarkup = markUp
+
+	_, err = r.bot.Send(msg)
+	if err != nil {
+		return fmt.Errorf("send series task: %w", err)
+	}
+
+	return nil
+}
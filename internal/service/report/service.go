@@ -0,0 +1,100 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"tgbot/internal/pkg/utils"
+	"tgbot/internal/repository"
+)
+
+type Service struct {
+	log   *zap.Logger
+	texts map[string]string
+	bot   *tgbotapi.BotAPI
+	repo  *repository.PGRepository
+}
+
+func NewReportService(log *zap.Logger, repo *repository.PGRepository, bot *tgbotapi.BotAPI, texts map[string]string) *Service {
+	return &Service{
+		log:   log,
+		repo:  repo,
+		bot:   bot,
+		texts: texts,
+	}
+}
+
+// SendDue sends the weekly workload report to the admins of every team whose
+// report day and time have come.
+func (r *Service) SendDue() error {
+	teams, err := r.repo.DueReports()
+	if err != nil {
+		return fmt.Errorf("get due reports: %w", err)
+	}
+
+	for _, teamID := range teams {
+		err = r.send(teamID)
+		if err != nil {
+			r.log.Error("send weekly report", zap.Int("team", teamID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// send claims the report of the team so that concurrent runs do not send it
+// twice, and gives the claim back when no admin got it so the next run retries.
+func (r *Service) send(teamID int) error {
+	marked, err := r.repo.MarkReportSent(teamID)
+	if err != nil || !marked {
+		return err
+	}
+
+	err = r.deliver(teamID)
+	if err != nil {
+		clearErr := r.repo.ClearReportSent(teamID)
+		if clearErr != nil {
+			r.log.Error("clear weekly report mark", zap.Int("team", teamID), zap.Error(clearErr))
+		}
+	}
+
+	return err
+}
+
+func (r *Service) deliver(teamID int) error {
+	name, err := r.repo.TeamName(teamID)
+	if err != nil {
+		return err
+	}
+
+	reports, err := r.repo.WorkloadReport(teamID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	admins, err := r.repo.TeamAdminsOf(teamID)
+	if err != nil {
+		return err
+	}
+
+	text := utils.FormatReport(r.texts, name, reports)
+	sent := 0
+	for _, id := range admins {
+		_, err = r.bot.Send(tgbotapi.NewMessage(id, text))
+		if err != nil {
+			r.log.Error("send weekly report to admin", zap.Int64("user", id), zap.Error(err))
+			continue
+		}
+
+		sent++
+	}
+
+	if sent == 0 && len(admins) > 0 {
+		return fmt.Errorf("no admin got the report: %w", err)
+	}
+
+	return nil
+}
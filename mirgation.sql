@@ -29,3 +29,243 @@ CREATE TABLE bot.user_team
     team_id int references bot.team (id),
     user_id bigint references bot.user (id)
 );
+
+
+ALTER TABLE bot.user_team
+    ADD COLUMN role text NOT NULL DEFAULT 'member';
+
+ALTER TABLE bot.task
+    ADD PRIMARY KEY (id),
+    ADD COLUMN creator_id bigint references bot.user (id),
+    ADD COLUMN team_id    int references bot.team (id),
+    ADD COLUMN status     text      NOT NULL DEFAULT 'open',
+    ADD COLUMN created_at timestamp NOT NULL DEFAULT now();
+
+CREATE TABLE bot.task_change
+(
+    id         SERIAL PRIMARY KEY,
+    task_id    int references bot.task (id) ON DELETE CASCADE,
+    user_id    bigint references bot.user (id),
+    field      text,
+    before     text,
+    after      text,
+    changed_at timestamp NOT NULL DEFAULT now()
+);
+
+ALTER TABLE bot.task
+    ADD COLUMN search tsvector GENERATED ALWAYS AS (to_tsvector('russian', coalesce(description, ''))) STORED;
+CREATE INDEX task_search_idx ON bot.task USING GIN (search);
+
+-- Existing timestamps were written in the zone of the bot host. Run the
+-- migration with the session TimeZone set to that zone.
+ALTER TABLE bot.user
+    ADD COLUMN time_zone text NOT NULL DEFAULT 'UTC',
+    ALTER COLUMN register_time TYPE timestamptz;
+ALTER TABLE bot.task
+    ALTER COLUMN deadline TYPE timestamptz,
+    ALTER COLUMN created_at TYPE timestamptz;
+ALTER TABLE bot.task_change
+    ALTER COLUMN changed_at TYPE timestamptz;
+
+CREATE TABLE bot.task_series
+(
+    id         SERIAL PRIMARY KEY,
+    creator_id bigint references bot.user (id),
+    rule       text        NOT NULL,
+    status     text        NOT NULL DEFAULT 'active',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE bot.task
+    ADD COLUMN series_id int references bot.task_series (id),
+    ADD COLUMN next_id   int references bot.task (id) ON DELETE SET NULL;
+CREATE INDEX task_series_next_idx ON bot.task (series_id) WHERE next_id IS NULL;
+
+CREATE TABLE bot.task_comment
+(
+    id         SERIAL PRIMARY KEY,
+    task_id    int references bot.task (id) ON DELETE CASCADE,
+    user_id    bigint references bot.user (id),
+    text       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX task_comment_task_idx ON bot.task_comment (task_id);
+
+CREATE TABLE bot.task_watcher
+(
+    task_id int references bot.task (id) ON DELETE CASCADE,
+    user_id bigint references bot.user (id),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE TABLE bot.task_attachment
+(
+    id             SERIAL PRIMARY KEY,
+    task_id        int references bot.task (id) ON DELETE CASCADE,
+    user_id        bigint references bot.user (id),
+    kind           varchar(16) NOT NULL,
+    file_id        text        NOT NULL,
+    file_unique_id text        NOT NULL,
+    file_name      text        NOT NULL DEFAULT '',
+    mime_type      text        NOT NULL DEFAULT '',
+    file_size      bigint      NOT NULL DEFAULT 0,
+    duration       int         NOT NULL DEFAULT 0,
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX task_attachment_task_idx ON bot.task_attachment (task_id);
+
+CREATE TABLE bot.task_item
+(
+    id       SERIAL PRIMARY KEY,
+    task_id  int references bot.task (id) ON DELETE CASCADE,
+    position int     NOT NULL,
+    text     text    NOT NULL,
+    done     boolean NOT NULL DEFAULT false
+);
+CREATE INDEX task_item_task_idx ON bot.task_item (task_id, position);
+
+CREATE TABLE bot.task_dependency
+(
+    task_id    int references bot.task (id) ON DELETE CASCADE,
+    blocker_id int references bot.task (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+CREATE INDEX task_dependency_blocker_idx ON bot.task_dependency (blocker_id);
+
+CREATE TABLE bot.team_label
+(
+    id      SERIAL PRIMARY KEY,
+    team_id int references bot.team (id) ON DELETE CASCADE,
+    name    varchar(32) NOT NULL,
+    UNIQUE (team_id, name)
+);
+
+CREATE TABLE bot.task_label
+(
+    task_id  int references bot.task (id) ON DELETE CASCADE,
+    label_id int references bot.team_label (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+ALTER TABLE bot.task
+    ADD COLUMN priority varchar(8) NOT NULL DEFAULT 'normal';
+
+ALTER TABLE bot.team
+    ADD COLUMN assign_policy    varchar(16) NOT NULL DEFAULT 'manual',
+    ADD COLUMN last_assignee_id bigint references bot.user (id);
+
+ALTER TABLE bot.user_team
+    ADD COLUMN available boolean NOT NULL DEFAULT true;
+
+ALTER TABLE bot.task
+    ADD COLUMN done_at timestamptz;
+
+ALTER TABLE bot.team
+    ADD COLUMN report_day     int  DEFAULT 1 CHECK (report_day BETWEEN 1 AND 7),
+    ADD COLUMN report_time    time NOT NULL DEFAULT '09:00',
+    ADD COLUMN report_tz      text NOT NULL DEFAULT 'UTC',
+    ADD COLUMN report_sent_at timestamptz;
+
+ALTER TABLE bot.task
+    ADD COLUMN assigned_at timestamptz NOT NULL DEFAULT now();
+
+ALTER TABLE bot."user"
+    ADD COLUMN digest_time    time,
+    ADD COLUMN digest_sent_at timestamptz;
+
+ALTER TABLE bot."user"
+    ADD COLUMN calendar_token text UNIQUE;
+
+CREATE TABLE bot.audit_log
+(
+    id         bigserial PRIMARY KEY,
+    actor_id   bigint,
+    action     text        NOT NULL,
+    target     text        NOT NULL,
+    target_id  text,
+    task_id    int,
+    team_id    int,
+    before     jsonb,
+    after      jsonb,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_team_idx ON bot.audit_log (team_id, id DESC);
+CREATE INDEX audit_log_task_idx ON bot.audit_log (task_id, id DESC);
+CREATE INDEX audit_log_actor_idx ON bot.audit_log (actor_id, id DESC);
+
+-- bot.audit records every write to the audited tables. The acting user is
+-- taken from the bot.actor setting of the transaction, NULL for the bot itself.
+-- The report and digest bookkeeping of the bot is left out, and an update
+-- touching only it is not recorded.
+CREATE FUNCTION bot.audit() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    old_row  jsonb;
+    new_row  jsonb;
+    rec      jsonb;
+    task_ref int;
+    team_ref int;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - 'password' - 'calendar_token' - 'invite_token' - 'search' - 'report_sent_at' - 'digest_sent_at';
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - 'password' - 'calendar_token' - 'invite_token' - 'search' - 'report_sent_at' - 'digest_sent_at';
+    END IF;
+    IF TG_OP = 'UPDATE' AND old_row = new_row THEN
+        RETURN NULL;
+    END IF;
+
+    rec := COALESCE(new_row, old_row);
+    task_ref := CASE WHEN TG_TABLE_NAME = 'task' THEN (rec ->> 'id')::int ELSE (rec ->> 'task_id')::int END;
+    team_ref := CASE
+        WHEN TG_TABLE_NAME = 'team' THEN (rec ->> 'id')::int
+        WHEN rec ? 'team_id' THEN (rec ->> 'team_id')::int
+        ELSE (SELECT t.team_id FROM bot.task t WHERE t.id = task_ref)
+    END;
+
+    INSERT INTO bot.audit_log (actor_id, action, target, target_id, task_id, team_id, before, after)
+    VALUES (NULLIF(current_setting('bot.actor', true), '')::bigint, lower(TG_OP), TG_TABLE_NAME,
+            COALESCE(rec ->> 'id', rec ->> 'user_id'), task_ref, team_ref, old_row, new_row);
+
+    RETURN NULL;
+END
+$$;
+
+CREATE FUNCTION bot.audit_append_only() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    RAISE EXCEPTION 'bot.audit_log is append-only';
+END
+$$;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE
+    ON bot.audit_log
+    FOR EACH STATEMENT
+EXECUTE FUNCTION bot.audit_append_only();
+
+DO
+$$
+DECLARE
+    t text;
+BEGIN
+    FOREACH t IN ARRAY ARRAY ['user', 'team', 'user_team', 'task', 'task_series', 'task_comment', 'task_watcher',
+        'task_attachment', 'task_item', 'task_dependency', 'team_label', 'task_label']
+        LOOP
+            EXECUTE format('CREATE TRIGGER audit AFTER INSERT OR UPDATE OR DELETE ON bot.%I
+                FOR EACH ROW EXECUTE FUNCTION bot.audit()', t);
+        END LOOP;
+END
+$$;
+
+ALTER TABLE bot.task
+    ADD COLUMN deleted_at timestamptz;
+CREATE INDEX task_deleted_idx ON bot.task (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE bot.team
+    ADD COLUMN invite_token text;